@@ -0,0 +1,12 @@
+package cas
+
+import (
+	"golang.org/x/sys/unix"
+	"os"
+)
+
+// reflink clones the content of src into dst without copying data, it only
+// works on file systems with copy-on-write support, e.g. btrfs and xfs.
+func reflink(dst, src *os.File) error {
+	return unix.IoctlFileClone(int(dst.Fd()), int(src.Fd()))
+}
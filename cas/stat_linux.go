@@ -0,0 +1,16 @@
+package cas
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// changeTime returns the ctime of info, which changes on any write, chmod
+// or utimes of the file.
+func changeTime(info os.FileInfo) time.Time {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(st.Ctim.Unix())
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package cas
+
+import (
+	"os"
+	"time"
+)
+
+func changeTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
@@ -0,0 +1,403 @@
+// Package cas implements a content-addressed blob store on local disk.
+// Blobs are addressed by the hex encoded SHA-256 of their content, and are
+// evicted in least-recently-used order when the store exceeds its size.
+package cas
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidDigest  = errors.New("invalid digest")
+	ErrBlobNotFound   = errors.New("blob not found")
+	ErrDigestMismatch = errors.New("digest mismatch")
+)
+
+const tmpPrefix = "tmp-"
+
+type entry struct {
+	digest string
+	size   int64
+	// linked is set once the blob is hardlinked, it may be modified through
+	// the link since. stat is recorded after the store changes the blob, and
+	// the blob is verified before it is used again if its stat changes, which
+	// is nil if unknown.
+	linked bool
+	stat   *blobStat
+}
+
+// blobStat changes if a linked blob is modified, since chmod and write both
+// change the ctime.
+type blobStat struct {
+	ctime time.Time
+	size  int64
+	mode  os.FileMode
+}
+
+// unchanged returns true if the opened blob still has stat s.
+func (s *blobStat) unchanged(f *os.File) bool {
+	cur, err := statBlob(f)
+	return err == nil && s != nil && s.ctime.Equal(cur.ctime) && s.size == cur.size && s.mode == cur.mode
+}
+
+func statBlob(f *os.File) (*blobStat, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &blobStat{ctime: changeTime(info), size: info.Size(), mode: info.Mode()}, nil
+}
+
+type Store struct {
+	dir      string
+	maxBytes int64
+	// hardlink materializes non-executable blobs by hard links. It is the
+	// fastest way, but the files are read-only, and the processes run by the
+	// same user can still modify the cached blob through the link after
+	// chmod. A modified blob is found and removed when it is used again, but
+	// the jobs sharing the blob before that see the modification.
+	hardlink bool
+
+	mutex   sync.Mutex
+	lru     *list.List // front is the most recently used
+	entries map[string]*list.Element
+	size    int64
+}
+
+// NewStore opens the store in dir, and indexes the blobs already in it.
+// maxBytes <= 0 means the store is unlimited.
+func NewStore(dir string, maxBytes int64, hardlink bool) (*Store, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cache dir %s: %w", dir, err)
+	}
+	s := &Store{
+		dir:      dir,
+		maxBytes: maxBytes,
+		hardlink: hardlink,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+	err = s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.evict("")
+	return s, nil
+}
+
+func (s *Store) load() error {
+	type blob struct {
+		entry
+		mtime time.Time
+	}
+	var blobs []blob
+	err := filepath.WalkDir(s.dir, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), tmpPrefix) {
+			return os.Remove(filename)
+		}
+		if ValidateDigest(d.Name()) != nil || filename != s.blobPath(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		blobs = append(blobs, blob{entry: entry{digest: d.Name(), size: info.Size()}, mtime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load cache dir %s: %w", s.dir, err)
+	}
+	sort.Slice(blobs, func(i, j int) bool {
+		return blobs[i].mtime.After(blobs[j].mtime)
+	})
+	for _, b := range blobs {
+		e := b.entry
+		// the blobs may be hardlinked before the restart.
+		e.linked = s.hardlink
+		s.entries[e.digest] = s.lru.PushBack(&e)
+		s.size += e.size
+	}
+	slog.Info("Loaded file cache", "blobs", len(blobs), "bytes", s.size, "dir", s.dir)
+	return nil
+}
+
+// ValidateDigest checks that digest is a lower case hex encoded SHA-256.
+func ValidateDigest(digest string) error {
+	if len(digest) != sha256.Size*2 || strings.ToLower(digest) != digest {
+		return fmt.Errorf("%w: %q", ErrInvalidDigest, digest)
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidDigest, digest)
+	}
+	return nil
+}
+
+func (s *Store) blobPath(digest string) string {
+	return path.Join(s.dir, digest[:2], digest)
+}
+
+// touch marks digest as recently used. It returns false if digest is not in
+// the store. mutex must be held.
+func (s *Store) touch(digest string) bool {
+	elem, ok := s.entries[digest]
+	if !ok {
+		return false
+	}
+	s.lru.MoveToFront(elem)
+	if elem.Value.(*entry).linked {
+		// changing the times of a linked blob changes its ctime.
+		return true
+	}
+	now := time.Now()
+	_ = os.Chtimes(s.blobPath(digest), now, now)
+	return true
+}
+
+// evict removes the least recently used blobs until the store fits in
+// maxBytes. keep is never evicted. mutex must be held.
+func (s *Store) evict(keep string) {
+	if s.maxBytes <= 0 {
+		return
+	}
+	for elem := s.lru.Back(); elem != nil && s.size > s.maxBytes; {
+		prev := elem.Prev()
+		e := elem.Value.(*entry)
+		if e.digest != keep {
+			err := os.Remove(s.blobPath(e.digest))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				slog.Warn("Failed to evict blob", "sha256", e.digest, "err", err)
+			} else {
+				slog.Debug("Evicted blob", "sha256", e.digest, "bytes", e.size)
+				s.lru.Remove(elem)
+				delete(s.entries, e.digest)
+				s.size -= e.size
+			}
+		}
+		elem = prev
+	}
+}
+
+// remove removes digest from the store. mutex must be held.
+func (s *Store) remove(digest string) {
+	elem, ok := s.entries[digest]
+	if !ok {
+		return
+	}
+	e := elem.Value.(*entry)
+	err := os.Remove(s.blobPath(digest))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Failed to remove blob", "sha256", digest, "err", err)
+	}
+	s.lru.Remove(elem)
+	delete(s.entries, digest)
+	s.size -= e.size
+}
+
+// verify checks the content of the opened blob, and restores its mode. The
+// offset of f is reset for reading.
+func verify(digest string, f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat blob %s: %w", digest, err)
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return fmt.Errorf("failed to read blob %s: %w", digest, err)
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != digest {
+		return fmt.Errorf("%w: expect %s, actual %s", ErrDigestMismatch, digest, actual)
+	}
+	_, err = f.Seek(0, io.SeekStart)
+	if err != nil {
+		return fmt.Errorf("failed to seek blob %s: %w", digest, err)
+	}
+	if info.Mode().Perm() == 0400 {
+		return nil
+	}
+	err = f.Chmod(0400)
+	if err != nil {
+		return fmt.Errorf("failed to chmod blob %s: %w", digest, err)
+	}
+	return nil
+}
+
+// recordStat records the stat of the opened blob after the store changes it.
+func (s *Store) recordStat(digest string, f *os.File) {
+	stat, err := statBlob(f)
+	if err != nil {
+		stat = nil // verified when it is used again.
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if elem, ok := s.entries[digest]; ok {
+		elem.Value.(*entry).stat = stat
+	}
+}
+
+// Missing returns the digests which are not in the store.
+func (s *Store) Missing(digests []string) (missing []string, err error) {
+	for _, digest := range digests {
+		if err = ValidateDigest(digest); err != nil {
+			return nil, err
+		}
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, digest := range digests {
+		if !s.touch(digest) {
+			missing = append(missing, digest)
+		}
+	}
+	return missing, nil
+}
+
+// Put stores the content read from reader as digest. The content is
+// verified before it is visible in the store.
+func (s *Store) Put(digest string, reader io.Reader) error {
+	err := ValidateDigest(digest)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(path.Dir(s.blobPath(digest)), 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(path.Dir(s.blobPath(digest)), tmpPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), reader)
+	err = errors.Join(err, tmp.Close())
+	if err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", digest, err)
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != digest {
+		return fmt.Errorf("%w: expect %s, actual %s", ErrDigestMismatch, digest, actual)
+	}
+	// blobs are shared by processes, they must not be modified.
+	err = os.Chmod(tmp.Name(), 0400)
+	if err != nil {
+		return fmt.Errorf("failed to chmod blob %s: %w", digest, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.touch(digest) {
+		return nil
+	}
+	err = os.Rename(tmp.Name(), s.blobPath(digest))
+	if err != nil {
+		return fmt.Errorf("failed to rename blob %s: %w", digest, err)
+	}
+	s.entries[digest] = s.lru.PushFront(&entry{digest: digest, size: size})
+	s.size += size
+	s.evict(digest)
+	return nil
+}
+
+// Materialize creates filename with the content of digest. The blob is
+// reflinked if the file system supports it, otherwise it is copied.
+// allowHardlink must be false if the file will be modified, e.g. chmod.
+// A hardlinked blob is verified before it is used again if its stat changes,
+// and it is removed from the store with ErrBlobNotFound if it is modified.
+// linked reports whether filename is a hard link of the blob.
+func (s *Store) Materialize(digest string, filename string, perm os.FileMode, allowHardlink bool) (linked bool, err error) {
+	err = ValidateDigest(digest)
+	if err != nil {
+		return false, err
+	}
+	s.mutex.Lock()
+	ok := s.touch(digest)
+	var wasLinked bool
+	var stat *blobStat
+	if ok {
+		e := s.entries[digest].Value.(*entry)
+		wasLinked, stat = e.linked, e.stat
+	}
+	s.mutex.Unlock()
+	if !ok {
+		return false, fmt.Errorf("%w: %s", ErrBlobNotFound, digest)
+	}
+
+	// the blob may be evicted concurrently, opening it first keeps the
+	// content alive while materializing.
+	src, err := os.Open(s.blobPath(digest))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, fmt.Errorf("%w: %s", ErrBlobNotFound, digest)
+		}
+		return false, fmt.Errorf("failed to open blob %s: %w", digest, err)
+	}
+	defer src.Close()
+	if wasLinked && !stat.unchanged(src) {
+		err = verify(digest, src)
+		if err != nil {
+			slog.Warn("Removing modified blob", "sha256", digest, "err", err)
+			s.mutex.Lock()
+			s.remove(digest)
+			s.mutex.Unlock()
+			return false, fmt.Errorf("%w: %s is modified", ErrBlobNotFound, digest)
+		}
+		s.recordStat(digest, src)
+	}
+
+	err = os.Remove(filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("failed to remove %s: %w", filename, err)
+	}
+	if s.hardlink && allowHardlink && perm&0111 == 0 {
+		err = os.Link(src.Name(), filename)
+		if err == nil {
+			s.mutex.Lock()
+			if elem, ok := s.entries[digest]; ok {
+				elem.Value.(*entry).linked = true
+			}
+			s.mutex.Unlock()
+			// linking changes the ctime.
+			s.recordStat(digest, src)
+			return true, nil
+		}
+		slog.Warn("Failed to hardlink blob, fallback to copy", "sha256", digest, "err", err)
+	}
+
+	dst, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return false, fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+	defer dst.Close()
+	if reflink(dst, src) == nil {
+		return false, nil
+	}
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		return false, fmt.Errorf("failed to copy blob %s to %s: %w", digest, filename, err)
+	}
+	return false, nil
+}
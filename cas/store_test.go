@@ -0,0 +1,153 @@
+package cas
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func digestOf(content string) string {
+	h := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(h[:])
+}
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStore(path.Join(dir, "cache"), 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hello := digestOf("hello")
+	missing, err := s.Missing([]string{hello})
+	if err != nil || len(missing) != 1 {
+		t.Fatalf("expect hello missing, %v, %v", missing, err)
+	}
+	if err = s.Put(hello, strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	missing, err = s.Missing([]string{hello})
+	if err != nil || len(missing) != 0 {
+		t.Fatalf("expect hello cached, %v, %v", missing, err)
+	}
+
+	filename := path.Join(dir, "hello.sh")
+	if _, err = s.Materialize(hello, filename, 0700, true); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("unexpected content %q, %v", data, err)
+	}
+	st, err := os.Stat(filename)
+	if err != nil || st.Mode().Perm() != 0700 {
+		t.Fatalf("unexpected mode %v, %v", st.Mode(), err)
+	}
+
+	// reopen the store, blobs are loaded from disk.
+	s, err = NewStore(path.Join(dir, "cache"), 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing, err = s.Missing([]string{hello})
+	if err != nil || len(missing) != 0 {
+		t.Fatalf("expect hello cached after reopen, %v, %v", missing, err)
+	}
+}
+
+func TestStoreErrors(t *testing.T) {
+	s, err := NewStore(t.TempDir(), 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Put("../../etc/passwd", strings.NewReader("")); !errors.Is(err, ErrInvalidDigest) {
+		t.Fatalf("expect invalid digest, got %v", err)
+	}
+	if err = s.Put(digestOf("hello"), strings.NewReader("world")); !errors.Is(err, ErrDigestMismatch) {
+		t.Fatalf("expect digest mismatch, got %v", err)
+	}
+	_, err = s.Materialize(digestOf("hello"), path.Join(t.TempDir(), "a"), 0600, true)
+	if !errors.Is(err, ErrBlobNotFound) {
+		t.Fatalf("expect blob not found, got %v", err)
+	}
+}
+
+func TestStoreEviction(t *testing.T) {
+	s, err := NewStore(t.TempDir(), 10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"aaaa", "bbbb", "cccc"} {
+		if err = s.Put(digestOf(content), strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+		if content == "bbbb" {
+			// use aaaa, so bbbb is the least recently used one.
+			if _, err = s.Missing([]string{digestOf("aaaa")}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	missing, err := s.Missing([]string{digestOf("aaaa"), digestOf("bbbb"), digestOf("cccc")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 1 || missing[0] != digestOf("bbbb") {
+		t.Fatalf("expect bbbb evicted, missing %v", missing)
+	}
+}
+
+func TestStoreHardlink(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewStore(path.Join(dir, "cache"), 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hello := digestOf("hello")
+	if err = s.Put(hello, strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	a := path.Join(dir, "a")
+	if _, err = s.Materialize(hello, a, 0600, true); err != nil {
+		t.Fatal(err)
+	}
+	linked, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob, err := os.Stat(s.blobPath(hello))
+	if err != nil || !os.SameFile(linked, blob) {
+		t.Fatalf("expect a hard link of the blob, %v", err)
+	}
+
+	// an unmodified blob is not hashed again, which records its stat again.
+	stat := s.entries[hello].Value.(*entry).stat
+	if _, err = s.Materialize(hello, path.Join(dir, "a2"), 0600, false); err != nil {
+		t.Fatal(err)
+	}
+	if s.entries[hello].Value.(*entry).stat != stat {
+		t.Fatal("the unmodified blob is verified again")
+	}
+	if _, err = s.Materialize(hello, path.Join(dir, "a3"), 0600, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// a job modifies the blob through the link.
+	if err = os.Chmod(a, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(a, []byte("world"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Materialize(hello, path.Join(dir, "b"), 0600, true)
+	if !errors.Is(err, ErrBlobNotFound) {
+		t.Fatalf("expect the modified blob not found, got %v", err)
+	}
+	missing, err := s.Missing([]string{hello})
+	if err != nil || len(missing) != 1 {
+		t.Fatalf("expect the modified blob missing, %v, %v", missing, err)
+	}
+}
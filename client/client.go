@@ -0,0 +1,188 @@
+// Package client runs commands on RCE servers. It implements the Spawn
+// handshake, i.e. the Head, the uploaded files and Start, and streams the
+// stdin, stdout and stderr of the remote command like os/exec.
+//
+//	c, err := client.Dial("localhost:8999")
+//	...
+//	defer c.Close()
+//	cmd := c.Command("uname", "-a")
+//	cmd.Stdout = os.Stdout
+//	result, err := cmd.Run(ctx)
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/reyoung/rce/protocol"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrUnsupported is returned if the server does not support a feature used
+// by the command.
+var ErrUnsupported = errors.New("not supported by server")
+
+// ServerError is an error reported by the server in a response, e.g. a
+// SystemError of Spawn.
+type ServerError struct {
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	return "server error: " + e.Message
+}
+
+// StartedError is a failure of the stream after the Start frame is sent.
+// The command may have run, so it is never run again by the client.
+type StartedError struct {
+	Err error
+}
+
+func (e *StartedError) Error() string {
+	return "stream failed after the command started: " + e.Err.Error()
+}
+
+func (e *StartedError) Unwrap() error {
+	return e.Err
+}
+
+// RetryPolicy retries the handshake of commands before Start is sent if
+// the server is unavailable. The handshake is idempotent, the files
+// uploaded by the failed attempts are removed by the server.
+type RetryPolicy struct {
+	// MaxAttempts of the handshake, <= 1 disables retries.
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt. It doubles
+	// after every attempt up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// backoff returns the wait after the attempt, starting from 1.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 {
+		d = min(d, p.MaxBackoff)
+	}
+	return d
+}
+
+// retryable returns whether the handshake failed by err can be retried.
+func retryable(err error) bool {
+	var startedErr *StartedError
+	return !errors.As(err, &startedErr) && status.Code(err) == codes.Unavailable
+}
+
+// blobEvicted returns whether the handshake failed because a cached file
+// is evicted by the server after it is found, then the file is uploaded
+// again by another attempt.
+func blobEvicted(err error) bool {
+	var startedErr *StartedError
+	return !errors.As(err, &startedErr) && status.Code(err) == codes.Aborted
+}
+
+// baselineFeatures are supported by the servers without the Info RPC.
+var baselineFeatures = []protocol.Feature{protocol.Feature_FEATURE_PTY}
+
+// FeatureName returns the name of f, e.g. output-buffer.
+func FeatureName(f protocol.Feature) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(f.String(), "FEATURE_")), "_", "-")
+}
+
+// Client calls an RCE server.
+type Client struct {
+	// Retry retries the handshake of the commands, the zero value disables
+	// retries.
+	Retry RetryPolicy
+
+	rce protocol.RemoteCodeExecutorClient
+	// conn is closed by Close if the connection is dialed by the client.
+	conn *grpc.ClientConn
+}
+
+// New returns a client of the server connected by conn.
+func New(conn grpc.ClientConnInterface) *Client {
+	return &Client{rce: protocol.NewRemoteCodeExecutorClient(conn)}
+}
+
+// Dial returns a client of the server at addr. The connection is insecure
+// unless credentials are set in opts. The connection is established lazily
+// by the first call.
+func Dial(addr string, opts ...grpc.DialOption) (*Client, error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s: %w", addr, err)
+	}
+	c := New(conn)
+	c.conn = conn
+	return c, nil
+}
+
+// Close closes the connection dialed by Dial.
+func (c *Client) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// Info returns the information of the server.
+func (c *Client) Info(ctx context.Context) (*protocol.InfoResponse, error) {
+	return c.rce.Info(ctx, &protocol.InfoRequest{})
+}
+
+// Drain stops the server from accepting new jobs, and waits for the running
+// jobs to end if wait is set. It returns the number of running jobs.
+func (c *Client) Drain(ctx context.Context, wait bool) (running uint32, err error) {
+	rsp, err := c.rce.Drain(ctx, &protocol.DrainRequest{Wait: wait})
+	if err != nil {
+		return 0, err
+	}
+	if rsp.Error != "" {
+		return rsp.Running, &ServerError{Message: rsp.Error}
+	}
+	return rsp.Running, nil
+}
+
+// CheckFeatures returns ErrUnsupported if the server does not support the
+// features. The server is asked only if features are given.
+func (c *Client) CheckFeatures(ctx context.Context, features ...protocol.Feature) error {
+	if len(features) == 0 {
+		return nil
+	}
+	supported := baselineFeatures
+	rsp, err := c.Info(ctx)
+	if err == nil {
+		supported = rsp.Features
+	} else if status.Code(err) != codes.Unimplemented {
+		return fmt.Errorf("failed to get server info: %w", err)
+	}
+	for _, f := range features {
+		if !slices.Contains(supported, f) {
+			return fmt.Errorf("%s is %w", FeatureName(f), ErrUnsupported)
+		}
+	}
+	return nil
+}
+
+// Command returns the Cmd to run name with args on the server.
+func (c *Client) Command(name string, args ...string) *Cmd {
+	return &Cmd{Path: name, Args: args, client: c}
+}
+
+// Run runs cmd on the server and waits for it to exit. cmd must not be
+// started.
+func (c *Client) Run(ctx context.Context, cmd *Cmd) (*Result, error) {
+	cmd.client = c
+	return cmd.Run(ctx)
+}
@@ -0,0 +1,330 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/reyoung/rce/cas"
+	"github.com/reyoung/rce/protocol"
+	"github.com/reyoung/rce/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func startTestServer(t *testing.T) *Client {
+	return startServer(t, &server.Server{})
+}
+
+func startServer(t *testing.T, s protocol.RemoteCodeExecutorServer) *Client {
+	lis := bufconn.Listen(1 << 20)
+	svr := grpc.NewServer()
+	protocol.RegisterRemoteCodeExecutorServer(svr, s)
+	go func() {
+		_ = svr.Serve(lis)
+	}()
+	t.Cleanup(svr.Stop)
+
+	c, err := Dial("passthrough:///bufconn",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+	return c
+}
+
+func TestRun(t *testing.T) {
+	c := startTestServer(t)
+	dir := t.TempDir()
+	cmd := c.Command("sh", "-c", `echo "$GREETING $(pwd)"; echo oops >&2; exit 3`)
+	cmd.Env = []string{"GREETING=hello"}
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	result, err := c.Run(context.Background(), cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ExitCode != 3 || result.PID == "" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if stdout.String() != "hello "+dir+"\n" || stderr.String() != "oops\n" {
+		t.Fatalf("unexpected stdout %q, stderr %q", stdout.String(), stderr.String())
+	}
+	if _, err = cmd.Run(context.Background()); err == nil {
+		t.Fatal("expect error of reusing a command")
+	}
+}
+
+func TestStdinPipe(t *testing.T) {
+	c := startTestServer(t)
+	cmd := c.Command("cat")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err = cmd.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.WriteString(stdin, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = stdin.Close()
+	result, err := cmd.Wait()
+	if err != nil || result.ExitCode != 0 || stdout.String() != "hello" {
+		t.Fatalf("unexpected result %+v, stdout %q, err %v", result, stdout.String(), err)
+	}
+}
+
+func TestUploadFiles(t *testing.T) {
+	c := startTestServer(t)
+	local := t.TempDir()
+	err := os.WriteFile(path.Join(local, "a.txt"), []byte("a\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(path.Join(local, "dir", "sub"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path.Join(local, "dir", "sub", "b.txt"), []byte("b\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := c.Command("cat", "a.txt", "dir/sub/b.txt")
+	cmd.Files = []File{
+		{Local: path.Join(local, "a.txt"), Remote: "a.txt"},
+		{Local: path.Join(local, "dir"), Remote: "dir"},
+	}
+	cmd.ArchiveCompression = protocol.SpawnRequest_Archive_ZSTD
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	result, err := cmd.Run(context.Background())
+	if err != nil || result.ExitCode != 0 || stdout.String() != "a\nb\n" {
+		t.Fatalf("unexpected result %+v, stdout %q, err %v", result, stdout.String(), err)
+	}
+
+	cmd = c.Command("true")
+	cmd.Files = []File{{Local: path.Join(local, "missing"), Remote: "missing"}}
+	_, err = cmd.Run(context.Background())
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expect error of missing file, got %v", err)
+	}
+}
+
+func TestServerError(t *testing.T) {
+	c := startTestServer(t)
+	cmd := c.Command("true")
+	cmd.Dir = path.Join(t.TempDir(), "missing")
+	_, err := cmd.Run(context.Background())
+	var serverErr *ServerError
+	if !errors.As(err, &serverErr) {
+		t.Fatalf("expect server error, got %v", err)
+	}
+}
+
+func TestSignal(t *testing.T) {
+	c := startTestServer(t)
+	cmd := c.Command("sh", "-c", `trap 'echo term; exit 7' TERM; echo ready; while true; do sleep 0.01; done`)
+	stdout, pw := io.Pipe()
+	cmd.Stdout = pw
+	err := cmd.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := make(chan string, 2)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := stdout.Read(buf)
+			if err != nil {
+				return
+			}
+			output <- strings.TrimSpace(string(buf[:n]))
+		}
+	}()
+	select {
+	case line := <-output:
+		if line != "ready" {
+			t.Fatalf("unexpected output %q", line)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("command is not ready")
+	}
+	err = cmd.Signal(syscall.SIGTERM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := cmd.Wait()
+	if err != nil || result.ExitCode != 7 {
+		t.Fatalf("unexpected result %+v, err %v", result, err)
+	}
+
+	cmd = c.Command("sleep", "10")
+	err = cmd.Start(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmd.Signal(syscall.SIGTERM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = cmd.Wait()
+	if err != nil || result.ExitCode != -1 || result.Signal != syscall.SIGTERM {
+		t.Fatalf("unexpected result %+v, err %v", result, err)
+	}
+}
+
+// flakyServer rejects the first failures spawns, and then loses the spawns
+// after Start if failAfterStart is set.
+type flakyServer struct {
+	*server.Server
+	failures       atomic.Int32
+	spawns         atomic.Int32
+	failAfterStart bool
+}
+
+func (s *flakyServer) Spawn(svr protocol.RemoteCodeExecutor_SpawnServer) error {
+	s.spawns.Add(1)
+	if s.failures.Add(-1) >= 0 { // like a draining server.
+		svr.SetTrailer(metadata.Pairs(protocol.NotStartedTrailer, "true"))
+		return status.Error(codes.Unavailable, "server is draining")
+	}
+	if !s.failAfterStart {
+		return s.Server.Spawn(svr)
+	}
+	for {
+		req, err := svr.Recv()
+		if err != nil {
+			return err
+		}
+		if req.GetStart() != nil {
+			return status.Error(codes.Unavailable, "connection reset")
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	s := &flakyServer{Server: &server.Server{}}
+	c := startServer(t, s)
+	c.Retry = RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+	s.failures.Store(2)
+	result, err := c.Command("true").Run(context.Background())
+	if err != nil || result.ExitCode != 0 || s.spawns.Load() != 3 {
+		t.Fatalf("unexpected result %+v, err %v after %d spawns", result, err, s.spawns.Load())
+	}
+
+	s.spawns.Store(0)
+	s.failures.Store(3)
+	_, err = c.Command("true").Run(context.Background())
+	var startedErr *StartedError
+	if status.Code(err) != codes.Unavailable || errors.As(err, &startedErr) || s.spawns.Load() != 3 {
+		t.Fatalf("expect unavailable before start, got %v after %d spawns", err, s.spawns.Load())
+	}
+
+	// never retried after Start.
+	s.spawns.Store(0)
+	s.failAfterStart = true
+	_, err = c.Command("true").Run(context.Background())
+	if !errors.As(err, &startedErr) || s.spawns.Load() != 1 {
+		t.Fatalf("expect started error, got %v after %d spawns", err, s.spawns.Load())
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, expected := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond,
+		400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		if d := p.backoff(attempt + 1); d != expected {
+			t.Fatalf("expect backoff %s after attempt %d, got %s", expected, attempt+1, d)
+		}
+	}
+}
+
+// evictingServer reports all blobs cached by the first FindMissingBlobs,
+// like a blob evicted before the Spawn.
+type evictingServer struct {
+	*server.Server
+	finds atomic.Int32
+}
+
+func (s *evictingServer) FindMissingBlobs(
+	ctx context.Context, req *protocol.FindMissingBlobsRequest) (*protocol.FindMissingBlobsResponse, error) {
+	if s.finds.Add(1) == 1 {
+		return &protocol.FindMissingBlobsResponse{}, nil
+	}
+	return s.Server.FindMissingBlobs(ctx, req)
+}
+
+func TestEvictedBlob(t *testing.T) {
+	store, err := cas.NewStore(t.TempDir(), 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &evictingServer{Server: &server.Server{BlobStore: store}}
+	c := startServer(t, s)
+	local := path.Join(t.TempDir(), "a.txt")
+	if err = os.WriteFile(local, []byte("a\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := c.Command("cat", "a.txt")
+	cmd.Files = []File{{Local: local, Remote: "a.txt"}}
+	cmd.Cache = true
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	result, err := cmd.Run(context.Background())
+	if err != nil || result.ExitCode != 0 || stdout.String() != "a\n" || s.finds.Load() != 2 {
+		t.Fatalf("unexpected result %+v, stdout %q, err %v after %d finds", result, stdout.String(), err,
+			s.finds.Load())
+	}
+}
+
+func TestCachedUploadHardlink(t *testing.T) {
+	store, err := cas.NewStore(t.TempDir(), 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := startServer(t, &server.Server{BlobStore: store})
+	dir := t.TempDir()
+	for name, mode := range map[string]os.FileMode{"ro.txt": 0444, "rw.txt": 0644} {
+		if err = os.WriteFile(path.Join(dir, name), []byte(name), mode); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.Chmod(path.Join(dir, name), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the link count is 2 if the file is a hard link of the blob.
+	cmd := c.Command("stat", "-c", "%n %h", "ro.txt", "rw.txt")
+	cmd.Files = []File{{Local: dir, Remote: "."}}
+	cmd.Cache = true
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	result, err := cmd.Run(context.Background())
+	if err != nil || result.ExitCode != 0 || stdout.String() != "ro.txt 2\nrw.txt 1\n" {
+		t.Fatalf("unexpected result %+v, stdout %q, err %v", result, stdout.String(), err)
+	}
+}
@@ -0,0 +1,462 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/reyoung/rce/protocol"
+	"github.com/reyoung/rce/tracing"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const sendBufSize = 4096
+
+// File is a local file or directory uploaded before the command starts.
+// Directories are uploaded as archives.
+type File struct {
+	Local string
+	// Remote is the path on the server, relative paths are relative to the
+	// working directory of the command.
+	Remote string
+}
+
+// Result is the result of an exited command.
+type Result struct {
+	PID string
+	// ExitCode is -1 if the command is killed by a signal.
+	ExitCode int
+	// Signal killed the command, 0 if the command exited or the server
+	// does not report the signal.
+	Signal syscall.Signal
+}
+
+// Cmd is a command run on the server, like exec.Cmd. A Cmd can not be
+// reused after Start.
+type Cmd struct {
+	// Path is the command to run.
+	Path string
+	// Args are the arguments of the command, without the command.
+	Args []string
+	// Env are the additional environment variables in the form key=value.
+	Env []string
+	// Dir is the working directory, empty runs the command in a temp dir.
+	Dir string
+
+	// Stdin is sent to the command until EOF. If Stdin is nil, the command
+	// has no stdin.
+	Stdin io.Reader
+	// Stdout and Stderr receive the output of the command, nil discards
+	// the output.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Files are uploaded before the command starts.
+	Files []File
+	// Cache uploads Files through the file cache of the server, only files
+	// missing in the cache are sent. Files are sent directly if the server
+	// does not enable the file cache.
+	Cache bool
+	// ArchiveCompression compresses uploaded directories.
+	ArchiveCompression protocol.SpawnRequest_Archive_Compression
+	// PreserveOwner preserves the owner of the uploaded files, the server
+	// must run as root.
+	PreserveOwner bool
+
+	// Pty runs the command in a pty of the size if not nil.
+	Pty *protocol.WindowSize
+	// SeparateStderr keeps stderr separated from the pty.
+	SeparateStderr bool
+	// MergeStderr redirects stderr to stdout, like 2>&1.
+	MergeStderr bool
+	// Priority of the job in the server queue, higher starts first.
+	Priority int32
+	// OutputBuffer configures the server side output buffer, nil uses the
+	// server default.
+	OutputBuffer *protocol.SpawnRequest_Head_OutputBuffer
+	// Timestamps prefixes each line of output with the time when the server
+	// captured it.
+	Timestamps bool
+
+	// OnQueued is called with the position of the job while it is queued.
+	OnQueued func(position uint32)
+	// OnGap is called when the server drops output because the output
+	// buffer is full.
+	OnGap func(stdoutBytes, stderrBytes uint64)
+
+	client    *Client
+	ctx       context.Context
+	cancel    context.CancelFunc
+	stream    protocol.RemoteCodeExecutor_SpawnClient
+	stdinPipe *io.PipeReader
+	stdout    outputWriter
+	stderr    outputWriter
+	pid       string
+	started   bool
+	// done is closed when the command exits or the stream fails.
+	done   chan struct{}
+	result *Result
+	err    error
+}
+
+// outputWriter writes the output frames of the command.
+type outputWriter interface {
+	Write(p []byte, timestampNs int64) error
+}
+
+type plainWriter struct {
+	w io.Writer
+}
+
+func (w plainWriter) Write(p []byte, _ int64) error {
+	_, err := w.w.Write(p)
+	return err
+}
+
+func newOutputWriter(w io.Writer, timestamps bool) outputWriter {
+	if w == nil {
+		w = io.Discard
+	}
+	if timestamps {
+		return &timestampWriter{w: w}
+	}
+	return plainWriter{w}
+}
+
+// PID returns the id of the started command on the server.
+func (c *Cmd) PID() string {
+	return c.pid
+}
+
+// StdinPipe returns a pipe connected to the stdin of the command. The pipe
+// is closed after Wait sees the command exit.
+func (c *Cmd) StdinPipe() (io.WriteCloser, error) {
+	if c.Stdin != nil {
+		return nil, errors.New("client: Stdin already set")
+	}
+	if c.started {
+		return nil, errors.New("client: StdinPipe after process started")
+	}
+	pr, pw := io.Pipe()
+	c.Stdin = pr
+	c.stdinPipe = pr
+	return pw, nil
+}
+
+func (c *Cmd) head() (*protocol.SpawnRequest_Head, error) {
+	h := &protocol.SpawnRequest_Head{
+		Command:        c.Path,
+		Args:           c.Args,
+		Path:           c.Dir,
+		HasStdin:       c.Stdin != nil,
+		AllocatePty:    c.Pty != nil,
+		WindowSize:     c.Pty,
+		VerifyFiles:    true,
+		OutputBuffer:   c.OutputBuffer,
+		MergeStderr:    c.MergeStderr,
+		SeparateStderr: c.SeparateStderr,
+		Priority:       c.Priority,
+	}
+	for _, env := range c.Env {
+		key, value, ok := strings.Cut(env, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid env %q, expect key=value", env)
+		}
+		h.Envs = append(h.Envs, &protocol.SpawnRequest_Head_Env{Key: key, Value: value})
+	}
+	return h, nil
+}
+
+// requiredFeatures returns the optional features used by the command.
+func (c *Cmd) requiredFeatures(head *protocol.SpawnRequest_Head) (features []protocol.Feature) {
+	if head.AllocatePty {
+		features = append(features, protocol.Feature_FEATURE_PTY)
+	}
+	if head.OutputBuffer.GetSize() != 0 ||
+		head.OutputBuffer.GetPolicy() != protocol.SpawnRequest_Head_OutputBuffer_BLOCK {
+		features = append(features, protocol.Feature_FEATURE_OUTPUT_BUFFER)
+	}
+	if c.Timestamps {
+		features = append(features, protocol.Feature_FEATURE_TIMESTAMPS)
+	}
+	if head.MergeStderr {
+		features = append(features, protocol.Feature_FEATURE_MERGE_STDERR)
+	}
+	if head.SeparateStderr {
+		features = append(features, protocol.Feature_FEATURE_SEPARATE_STDERR)
+	}
+	if head.Priority != 0 {
+		features = append(features, protocol.Feature_FEATURE_PRIORITY)
+	}
+	for _, f := range c.Files {
+		if info, err := os.Stat(f.Local); err == nil && info.IsDir() && !c.Cache {
+			features = append(features, protocol.Feature_FEATURE_ARCHIVE)
+			break
+		}
+	}
+	return features
+}
+
+// Start starts the command on the server. It returns after the files are
+// uploaded and the command is started, so it blocks while the job is
+// queued. The command is killed if ctx is done. The handshake is retried by
+// the RetryPolicy of the client until Start is sent, the failures after
+// that are *StartedError. If a cached file is evicted by the server during
+// the handshake, it is retried once more to upload the file again.
+func (c *Cmd) Start(ctx context.Context) error {
+	if c.client == nil {
+		return errors.New("client: Cmd is not created by a Client")
+	}
+	if c.started {
+		return errors.New("client: already started")
+	}
+	c.started = true
+	head, err := c.head()
+	if err != nil {
+		return err
+	}
+	c.stdout = newOutputWriter(c.Stdout, c.Timestamps)
+	c.stderr = newOutputWriter(c.Stderr, c.Timestamps)
+	features := c.requiredFeatures(head)
+	policy := &c.client.Retry
+	reuploaded := false
+	for attempt := 1; ; attempt++ {
+		err = c.client.CheckFeatures(ctx, features...)
+		if err == nil {
+			c.ctx, c.cancel = context.WithCancel(ctx)
+			err = c.start(head)
+			if err != nil {
+				c.cancel()
+			}
+		}
+		if err != nil && !reuploaded && blobEvicted(err) {
+			// not counted by the policy, the server is available.
+			reuploaded = true
+			attempt--
+			continue
+		}
+		if err == nil || attempt >= policy.MaxAttempts || !retryable(err) {
+			break
+		}
+		select {
+		case <-time.After(policy.backoff(attempt)):
+		case <-ctx.Done():
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+	c.done = make(chan struct{})
+	if c.Stdin != nil {
+		go c.sendStdin()
+	}
+	go c.receive()
+	return nil
+}
+
+// start sends the Head, the files and Start, and waits for the PID.
+func (c *Cmd) start(head *protocol.SpawnRequest_Head) (err error) {
+	c.stream, err = c.client.rce.Spawn(c.ctx)
+	if err != nil {
+		return fmt.Errorf("failed to spawn: %w", err)
+	}
+	_, span := tracing.Tracer().Start(c.ctx, "head")
+	err = c.send(&protocol.SpawnRequest{Payload: &protocol.SpawnRequest_Head_{Head: head}})
+	span.End()
+	if err != nil {
+		return err
+	}
+	u := &uploader{
+		ctx:           c.ctx,
+		rceClient:     c.client.rce,
+		send:          c.send,
+		compression:   c.ArchiveCompression,
+		preserveOwner: c.PreserveOwner,
+	}
+	err = u.uploadFiles(c.Files, c.Cache)
+	if err != nil {
+		return err
+	}
+	// Send fails if the stream is ended before Start is sent, otherwise the
+	// command may run.
+	err = c.send(&protocol.SpawnRequest{Payload: &protocol.SpawnRequest_Start_{Start: &protocol.SpawnRequest_Start{}}})
+	if err != nil {
+		return err
+	}
+	// the PID is the first response after the job is dequeued.
+	for c.pid == "" {
+		rsp, err := c.stream.Recv()
+		if err != nil {
+			if slices.Contains(c.stream.Trailer().Get(protocol.NotStartedTrailer), "true") {
+				return recvError(err)
+			}
+			return &StartedError{Err: recvError(err)}
+		}
+		_, err = c.handle(rsp)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send sends a frame before the command starts. If the server ends the
+// stream, the error of the stream is returned.
+func (c *Cmd) send(req *protocol.SpawnRequest) error {
+	err := c.stream.Send(req)
+	if err != io.EOF {
+		return err
+	}
+	for {
+		rsp, err := c.stream.Recv()
+		if err != nil {
+			return recvError(err)
+		}
+		if rsp.GetError() != nil {
+			return &ServerError{Message: rsp.GetError().Error}
+		}
+	}
+}
+
+func recvError(err error) error {
+	if err == io.EOF {
+		return fmt.Errorf("stream ended before the command exited: %w", io.ErrUnexpectedEOF)
+	}
+	return err
+}
+
+// handle processes a response, it returns true if the command exited.
+func (c *Cmd) handle(rsp *protocol.SpawnResponse) (exited bool, err error) {
+	switch p := rsp.Payload.(type) {
+	case *protocol.SpawnResponse_Pid:
+		c.pid = p.Pid.Id
+	case *protocol.SpawnResponse_Stdout_:
+		err = c.stdout.Write(p.Stdout.Stdout, p.Stdout.TimestampNs)
+		if err != nil {
+			return false, fmt.Errorf("failed to write stdout: %w", err)
+		}
+	case *protocol.SpawnResponse_Stderr_:
+		err = c.stderr.Write(p.Stderr.Stderr, p.Stderr.TimestampNs)
+		if err != nil {
+			return false, fmt.Errorf("failed to write stderr: %w", err)
+		}
+	case *protocol.SpawnResponse_Exit_:
+		c.result = &Result{PID: c.pid, ExitCode: int(p.Exit.Code), Signal: syscall.Signal(p.Exit.Signal)}
+		return true, nil
+	case *protocol.SpawnResponse_Error:
+		return false, &ServerError{Message: p.Error.Error}
+	case *protocol.SpawnResponse_Gap_:
+		if c.OnGap != nil {
+			c.OnGap(p.Gap.StdoutBytes, p.Gap.StderrBytes)
+		}
+	case *protocol.SpawnResponse_Queued_:
+		if c.OnQueued != nil {
+			c.OnQueued(p.Queued.Position)
+		}
+	}
+	return false, nil
+}
+
+// sendStdin sends Stdin to the command until EOF. A read error closes the
+// stdin of the command too.
+func (c *Cmd) sendStdin() {
+	var buf [sendBufSize]byte
+	for {
+		n, err := c.Stdin.Read(buf[:])
+		if n > 0 {
+			// a failed stream is reported by receive.
+			if c.stream.Send(&protocol.SpawnRequest{Payload: &protocol.SpawnRequest_Stdin_{
+				Stdin: &protocol.SpawnRequest_Stdin{Stdin: buf[:n]}}}) != nil {
+				return
+			}
+		}
+		if err != nil {
+			_ = c.stream.Send(&protocol.SpawnRequest{Payload: &protocol.SpawnRequest_Stdin_{
+				Stdin: &protocol.SpawnRequest_Stdin{Eof: true}}})
+			return
+		}
+	}
+}
+
+func (c *Cmd) receive() {
+	defer close(c.done)
+	for {
+		rsp, err := c.stream.Recv()
+		if err != nil {
+			c.err = &StartedError{Err: recvError(err)}
+			return
+		}
+		exited, err := c.handle(rsp)
+		if err != nil {
+			c.err = err
+			return
+		}
+		if exited {
+			return
+		}
+	}
+}
+
+// Wait waits for the started command to exit. The error is nil if the exit
+// code is received, even if it is not 0.
+func (c *Cmd) Wait() (*Result, error) {
+	if c.done == nil {
+		return nil, errors.New("client: not started")
+	}
+	<-c.done
+	// end the stream, the command is killed if it is still running.
+	c.cancel()
+	_, _ = c.stream.Recv()
+	if c.stdinPipe != nil {
+		_ = c.stdinPipe.CloseWithError(os.ErrClosed)
+	}
+	return c.result, c.err
+}
+
+// Run starts the command and waits for it to exit.
+func (c *Cmd) Run(ctx context.Context) (*Result, error) {
+	err := c.Start(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.Wait()
+}
+
+// Signal sends sig to the process group of the started command. Signals
+// other than SIGKILL require FEATURE_SIGNAL of the server.
+func (c *Cmd) Signal(sig os.Signal) error {
+	if c.pid == "" {
+		return errors.New("client: not started")
+	}
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("unsupported signal %v", sig)
+	}
+	ctx := context.WithoutCancel(c.ctx)
+	req := &protocol.PID{Id: c.pid}
+	if s != syscall.SIGKILL {
+		err := c.client.CheckFeatures(ctx, protocol.Feature_FEATURE_SIGNAL)
+		if err != nil {
+			return err
+		}
+		req.Signal = int32(s)
+	}
+	rsp, err := c.client.rce.Kill(ctx, req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s: %w", s, err)
+	}
+	if rsp.GetError() != "" {
+		return &ServerError{Message: rsp.GetError()}
+	}
+	return nil
+}
+
+// Kill kills the process group of the started command.
+func (c *Cmd) Kill() error {
+	return c.Signal(syscall.SIGKILL)
+}
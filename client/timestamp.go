@@ -0,0 +1,39 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"time"
+)
+
+const timestampLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+// timestampWriter prefixes every line written to it with the capture time of
+// the output frame which starts the line.
+type timestampWriter struct {
+	w       io.Writer
+	midLine bool
+	buf     []byte
+}
+
+func (w *timestampWriter) Write(p []byte, timestampNs int64) error {
+	prefix := time.Unix(0, timestampNs).Format(timestampLayout) + " "
+	buf := w.buf[:0]
+	for len(p) > 0 {
+		if !w.midLine {
+			buf = append(buf, prefix...)
+		}
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			buf = append(buf, p...)
+			w.midLine = true
+			break
+		}
+		buf = append(buf, p[:i+1]...)
+		p = p[i+1:]
+		w.midLine = false
+	}
+	w.buf = buf
+	_, err := w.w.Write(buf)
+	return err
+}
@@ -0,0 +1,448 @@
+package client
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"github.com/reyoung/rce/protocol"
+	"github.com/reyoung/rce/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"syscall"
+)
+
+const archiveChunkSize = 256 * 1024
+
+type uploader struct {
+	ctx       context.Context
+	rceClient protocol.RemoteCodeExecutorClient
+	// send sends a frame of the Spawn stream.
+	send          func(req *protocol.SpawnRequest) error
+	compression   protocol.SpawnRequest_Archive_Compression
+	preserveOwner bool
+}
+
+// unixMode converts os.FileMode to unix permission bits.
+func unixMode(mode os.FileMode) uint32 {
+	m := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		m |= syscall.S_ISUID
+	}
+	if mode&os.ModeSetgid != 0 {
+		m |= syscall.S_ISGID
+	}
+	if mode&os.ModeSticky != 0 {
+		m |= syscall.S_ISVTX
+	}
+	return m
+}
+
+func (u *uploader) fileMetadata(filename string, info os.FileInfo) (*protocol.FileMetadata, error) {
+	md := &protocol.FileMetadata{
+		Mode:    unixMode(info.Mode()),
+		MtimeNs: info.ModTime().UnixNano(),
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(filename)
+		if err != nil {
+			return nil, err
+		}
+		md.SymlinkTarget = target
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && u.preserveOwner {
+		md.Owner = &protocol.FileMetadata_Owner{Uid: st.Uid, Gid: st.Gid}
+	}
+	return md, nil
+}
+
+func (u *uploader) sendFile(file *protocol.SpawnRequest_File) error {
+	return u.send(&protocol.SpawnRequest{Payload: &protocol.SpawnRequest_File_{File: file}})
+}
+
+func (u *uploader) uploadFile(local, remote string, info os.FileInfo) error {
+	md, err := u.fileMetadata(local, info)
+	if err != nil {
+		return err
+	}
+	if md.SymlinkTarget != "" {
+		return u.sendFile(&protocol.SpawnRequest_File{Filename: remote, Eof: true, Metadata: md})
+	}
+	executable := info.Mode()&0100 != 0
+
+	f, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var buf [sendBufSize]byte
+	trun := true
+	h := sha256.New()
+	var size uint64
+	for {
+		n, err := f.Read(buf[:])
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		h.Write(buf[:n])
+		size += uint64(n)
+		err = u.sendFile(&protocol.SpawnRequest_File{
+			Filename:   remote,
+			Content:    buf[:n],
+			Executable: executable,
+			Truncate:   trun,
+		})
+		if err != nil {
+			return err
+		}
+		trun = false
+	}
+	// commit the file, the server verifies it.
+	return u.sendFile(&protocol.SpawnRequest_File{
+		Filename:   remote,
+		Executable: executable,
+		Truncate:   trun,
+		Eof:        true,
+		Size:       size,
+		Sha256:     hex.EncodeToString(h.Sum(nil)),
+		Metadata:   md,
+	})
+}
+
+// archiveFrameWriter sends everything written to it as archive frames.
+type archiveFrameWriter struct {
+	send          func(req *protocol.SpawnRequest) error
+	remote        string
+	compression   protocol.SpawnRequest_Archive_Compression
+	preserveOwner bool
+}
+
+func (w *archiveFrameWriter) sendArchive(content []byte, eof bool) error {
+	return w.send(&protocol.SpawnRequest{Payload: &protocol.SpawnRequest_Archive_{
+		Archive: &protocol.SpawnRequest_Archive{
+			Path:          w.remote,
+			Content:       content,
+			Compression:   w.compression,
+			Eof:           eof,
+			PreserveOwner: w.preserveOwner,
+		},
+	}})
+}
+
+func (w *archiveFrameWriter) Write(p []byte) (int, error) {
+	for i := 0; i < len(p); i += archiveChunkSize {
+		err := w.sendArchive(p[i:min(i+archiveChunkSize, len(p))], false)
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(p), nil
+}
+
+func newCompressWriter(compression protocol.SpawnRequest_Archive_Compression, w io.Writer) (io.WriteCloser, error) {
+	switch compression {
+	case protocol.SpawnRequest_Archive_GZIP:
+		return gzip.NewWriter(w), nil
+	case protocol.SpawnRequest_Archive_ZSTD:
+		return zstd.NewWriter(w)
+	default:
+		return nopWriteCloser{w}, nil
+	}
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func writeArchiveEntry(tw *tar.Writer, dir, filename string, d fs.DirEntry) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	link := ""
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err = os.Readlink(filename)
+		if err != nil {
+			return err
+		}
+	case !info.IsDir() && !info.Mode().IsRegular():
+		return fmt.Errorf("upload %s is not supported, only regular files, dirs and symlinks", filename)
+	}
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(dir, filename)
+	if err != nil {
+		return err
+	}
+	hdr.Name = filepath.ToSlash(rel)
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	err = tw.WriteHeader(hdr)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+func (u *uploader) uploadArchive(local, remote string) error {
+	fw := &archiveFrameWriter{
+		send:          u.send,
+		remote:        remote,
+		compression:   u.compression,
+		preserveOwner: u.preserveOwner,
+	}
+	bw := bufio.NewWriterSize(fw, archiveChunkSize)
+	cw, err := newCompressWriter(u.compression, bw)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(cw)
+	err = filepath.WalkDir(local, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filename == local {
+			return nil
+		}
+		return writeArchiveEntry(tw, local, filename, d)
+	})
+	if err != nil {
+		return err
+	}
+	for _, c := range []io.Closer{tw, cw} {
+		if err = c.Close(); err != nil {
+			return err
+		}
+	}
+	if err = bw.Flush(); err != nil {
+		return err
+	}
+	return fw.sendArchive(nil, true)
+}
+
+func (u *uploader) upload(local, remote string) error {
+	_, span := tracing.Tracer().Start(u.ctx, "upload", trace.WithAttributes(
+		attribute.String("local", local), attribute.String("remote", remote)))
+	defer span.End()
+	info, err := os.Stat(local)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return u.uploadArchive(local, remote)
+	}
+
+	return u.uploadFile(local, remote, info)
+}
+
+type cachedUpload struct {
+	local    string
+	remote   string
+	info     os.FileInfo
+	sha256   string
+	metadata *protocol.FileMetadata
+}
+
+func fileSha256(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (u *uploader) listCachedUploads(local, remote string) (uploads []cachedUpload, err error) {
+	err = filepath.WalkDir(local, func(filename string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		isSymlink := info.Mode()&os.ModeSymlink != 0
+		if filename == local && isSymlink { // follow the uploaded path, like os.Stat in upload
+			info, err = os.Stat(filename)
+			if err != nil {
+				return err
+			}
+			isSymlink = false
+		}
+		if !isSymlink && !info.Mode().IsRegular() {
+			return fmt.Errorf("upload %s is not supported, only regular files, dirs and symlinks", filename)
+		}
+		rel, err := filepath.Rel(local, filename)
+		if err != nil {
+			return err
+		}
+		upload := cachedUpload{
+			local:  filename,
+			remote: path.Join(remote, filepath.ToSlash(rel)),
+			info:   info,
+		}
+		upload.metadata, err = u.fileMetadata(filename, info)
+		if err != nil {
+			return err
+		}
+		if !isSymlink {
+			upload.sha256, err = fileSha256(filename)
+			if err != nil {
+				return err
+			}
+		}
+		uploads = append(uploads, upload)
+		return nil
+	})
+	return uploads, err
+}
+
+func (u *uploader) putBlob(local, digest string) error {
+	_, span := tracing.Tracer().Start(u.ctx, "put blob", trace.WithAttributes(
+		attribute.String("local", local), attribute.String("sha256", digest)))
+	defer span.End()
+	cli, err := u.rceClient.PutBlob(u.ctx)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var buf [archiveChunkSize]byte
+	req := &protocol.PutBlobRequest{Sha256: digest}
+	var sendErr error
+	for sendErr == nil {
+		n, err := f.Read(buf[:])
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		req.Content = buf[:n]
+		sendErr = cli.Send(req) // the error of the stream is returned by CloseAndRecv.
+		req = &protocol.PutBlobRequest{}
+	}
+	if req.Sha256 != "" { // empty file
+		_ = cli.Send(req)
+	}
+	rsp, err := cli.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	if rsp.Error != "" {
+		return &ServerError{Message: fmt.Sprintf("failed to upload %s to file cache, %s", local, rsp.Error)}
+	}
+	return nil
+}
+
+// cachedUpload uploads files through the file cache. It returns false if
+// the server does not enable the file cache.
+func (u *uploader) cachedUpload(files []File) (bool, error) {
+	var uploads []cachedUpload
+	localOf := make(map[string]string)
+	var digests []string
+	for _, file := range files {
+		list, err := u.listCachedUploads(file.Local, file.Remote)
+		if err != nil {
+			return false, err
+		}
+		for _, upload := range list {
+			uploads = append(uploads, upload)
+			if _, ok := localOf[upload.sha256]; !ok && upload.sha256 != "" {
+				localOf[upload.sha256] = upload.local
+				digests = append(digests, upload.sha256)
+			}
+		}
+	}
+
+	rsp, err := u.rceClient.FindMissingBlobs(u.ctx,
+		&protocol.FindMissingBlobsRequest{Sha256: digests})
+	if err != nil {
+		return false, err
+	}
+	if rsp.Error != "" { // the file cache is not available.
+		return false, nil
+	}
+	for _, digest := range rsp.Missing {
+		if err = u.putBlob(localOf[digest], digest); err != nil {
+			return false, err
+		}
+	}
+	for _, upload := range uploads {
+		if upload.sha256 == "" { // symlink
+			err = u.sendFile(&protocol.SpawnRequest_File{Filename: upload.remote, Eof: true, Metadata: upload.metadata})
+		} else {
+			err = u.send(&protocol.SpawnRequest{Payload: &protocol.SpawnRequest_CachedFile_{
+				CachedFile: &protocol.SpawnRequest_CachedFile{
+					Filename:   upload.remote,
+					Sha256:     upload.sha256,
+					Executable: upload.info.Mode()&0100 != 0,
+					Metadata:   upload.metadata,
+				},
+			}})
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// uploadFiles uploads files, through the file cache if cache is set and the
+// server enables the file cache.
+func (u *uploader) uploadFiles(files []File, cache bool) error {
+	if len(files) == 0 {
+		return nil
+	}
+	if cache {
+		ok, err := u.cachedUpload(files)
+		if ok || err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		err := u.upload(file.Local, file.Remote)
+		if err != nil {
+			return fmt.Errorf("failed to upload %s: %w", file.Local, err)
+		}
+	}
+	return nil
+}
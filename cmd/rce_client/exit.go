@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/reyoung/rce/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// Exit codes of rce_client, which are documented in the usage. Otherwise
+// rce_client exits with the exit code of the remote command.
+const (
+	exitUsage       = 64
+	exitLocal       = 66
+	exitUnavailable = 69
+	exitRejected    = 70
+	exitProtocol    = 76
+	exitSignal      = 128
+)
+
+// usageError is an error of the arguments.
+type usageError struct {
+	error
+}
+
+func usageErrorf(format string, a ...any) error {
+	return usageError{fmt.Errorf(format, a...)}
+}
+
+// exitCodeOf returns the exit code of a failure of rce_client.
+func exitCodeOf(err error) int {
+	var usageErr usageError
+	var serverErr *client.ServerError
+	var startedErr *client.StartedError
+	var pathErr *fs.PathError
+	switch {
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.As(err, &serverErr), errors.Is(err, client.ErrUnsupported):
+		return exitRejected
+	case errors.As(err, &startedErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return exitProtocol
+	case errors.As(err, &pathErr):
+		return exitLocal
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Aborted:
+		return exitUnavailable
+	case codes.PermissionDenied, codes.Unauthenticated, codes.ResourceExhausted, codes.FailedPrecondition,
+		codes.InvalidArgument, codes.NotFound:
+		return exitRejected
+	}
+	return exitProtocol
+}
+
+// resultExitCode returns the exit code of the remote command. A command
+// killed by signal N exits with 128+N like in shells.
+func resultExitCode(result *client.Result) int {
+	switch {
+	case result.Signal != 0:
+		return exitSignal + int(result.Signal)
+	case result.ExitCode < 0: // the server does not report the signal.
+		return exitSignal
+	}
+	return result.ExitCode
+}
+
+// errorMessage returns the message of err for humans. gRPC errors are
+// printed without the status code.
+func errorMessage(err error, addr string) string {
+	var st interface {
+		error
+		GRPCStatus() *status.Status
+	}
+	if !errors.As(err, &st) {
+		return err.Error()
+	}
+	msg := strings.Replace(err.Error(), st.Error(), st.GRPCStatus().Message(), 1)
+	if exitCodeOf(err) == exitUnavailable {
+		return fmt.Sprintf("server %s is unavailable: %s", addr, msg)
+	}
+	return msg
+}
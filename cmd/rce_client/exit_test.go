@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/reyoung/rce/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestExitCodeOf(t *testing.T) {
+	_, pathErr := os.Open("/nonexistent")
+	for _, c := range []struct {
+		err  error
+		code int
+	}{
+		{usageErrorf("invalid env"), exitUsage},
+		{fmt.Errorf("failed to upload: %w", pathErr), exitLocal},
+		{status.Error(codes.Unavailable, "server is draining"), exitUnavailable},
+		{status.Error(codes.Aborted, "blob not found"), exitUnavailable},
+		{fmt.Errorf("failed to spawn: %w", status.Error(codes.PermissionDenied, "uid 1000")), exitRejected},
+		{&client.ServerError{Message: "failed to start command"}, exitRejected},
+		{fmt.Errorf("pty is %w", client.ErrUnsupported), exitRejected},
+		{fmt.Errorf("stream ended: %w", io.ErrUnexpectedEOF), exitProtocol},
+		{&client.StartedError{Err: status.Error(codes.Unavailable, "transport is closing")}, exitProtocol},
+		{status.Error(codes.Internal, "bad frame"), exitProtocol},
+	} {
+		if code := exitCodeOf(c.err); code != c.code {
+			t.Errorf("expect exit code %d of %v, got %d", c.code, c.err, code)
+		}
+	}
+}
+
+func TestResultExitCode(t *testing.T) {
+	for _, c := range []struct {
+		result client.Result
+		code   int
+	}{
+		{client.Result{ExitCode: 3}, 3},
+		{client.Result{ExitCode: -1, Signal: syscall.SIGTERM}, 143},
+		{client.Result{ExitCode: -1}, exitSignal},
+	} {
+		if code := resultExitCode(&c.result); code != c.code {
+			t.Errorf("expect exit code %d of %+v, got %d", c.code, c.result, code)
+		}
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	err := fmt.Errorf("failed to spawn: %w", status.Error(codes.Unavailable, "connection refused"))
+	msg := errorMessage(err, "host:8999")
+	if msg != "server host:8999 is unavailable: failed to spawn: connection refused" {
+		t.Fatalf("unexpected message %q", msg)
+	}
+	if msg = errorMessage(errors.New("oops"), "host:8999"); msg != "oops" {
+		t.Fatalf("unexpected message %q", msg)
+	}
+}
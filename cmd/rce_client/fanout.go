@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/docopt/docopt-go"
+	"github.com/reyoung/rce/client"
+	"github.com/reyoung/rce/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// readHosts returns the addresses of --hosts or --hosts-file.
+func readHosts(arguments docopt.Opts) ([]string, error) {
+	var lines []string
+	if hosts, ok := arguments["--hosts"].(string); ok {
+		lines = strings.Split(hosts, ",")
+	} else {
+		data, err := os.ReadFile(arguments["--hosts-file"].(string))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hosts file: %w", err)
+		}
+		lines = strings.Split(string(data), "\n")
+	}
+	var hosts []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hosts = append(hosts, line)
+	}
+	if len(hosts) == 0 {
+		return nil, usageErrorf("no hosts to run the command on")
+	}
+	return hosts, nil
+}
+
+// maxLineSize is the max size of a line buffered by prefixWriter, longer
+// lines are split.
+const maxLineSize = 64 << 10
+
+// prefixWriter prefixes each line written to w. Only complete lines are
+// written, under a mutex shared by the writers of all hosts, so the lines of
+// different hosts are not interleaved.
+type prefixWriter struct {
+	w      io.Writer
+	mutex  *sync.Mutex
+	prefix []byte
+	buf    []byte // the incomplete line.
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	end := bytes.LastIndexByte(w.buf, '\n') + 1
+	if end == 0 && len(w.buf) < maxLineSize {
+		return len(p), nil
+	}
+	if end == 0 {
+		end = len(w.buf)
+	}
+	err := w.writeLines(w.buf[:end])
+	w.buf = w.buf[:copy(w.buf, w.buf[end:])]
+	return len(p), err
+}
+
+// Flush writes the incomplete line, if any, with a newline.
+func (w *prefixWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.writeLines(w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+func (w *prefixWriter) writeLines(lines []byte) error {
+	var out []byte
+	for len(lines) > 0 {
+		line, rest, _ := bytes.Cut(lines, []byte{'\n'})
+		out = append(out, w.prefix...)
+		out = append(out, line...)
+		out = append(out, '\n')
+		lines = rest
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	_, err := w.w.Write(out)
+	return err
+}
+
+// hostResult is the result of the command on a host.
+type hostResult struct {
+	host string
+	code int
+	err  error
+}
+
+// doFanout runs the command on all hosts concurrently, at most --parallel at
+// a time, and prints the exit code of each host at the end. It returns the
+// highest exit code of the hosts.
+func doFanout(ctx context.Context, arguments docopt.Opts, connectTimeout time.Duration,
+	dialOpts []grpc.DialOption) (int, error) {
+	hosts, err := readHosts(arguments)
+	if err != nil {
+		return 0, err
+	}
+	parallel, err := strconv.Atoi(arguments["--parallel"].(string))
+	if err != nil || parallel <= 0 {
+		return 0, usageErrorf("invalid parallel %s", arguments["--parallel"])
+	}
+	policy, err := retryPolicy(arguments)
+	if err != nil {
+		return 0, err
+	}
+	// the arguments are checked once, instead of failing on every host.
+	if err = prepareCmd(&client.Cmd{}, arguments); err != nil {
+		return 0, err
+	}
+
+	var mutex sync.Mutex
+	results := make([]hostResult, len(hosts))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i].host = host
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i].err = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+			results[i].code, results[i].err = runOnHost(ctx, arguments, host, &mutex, policy, connectTimeout,
+				dialOpts)
+		}()
+	}
+	wg.Wait()
+
+	code := 0
+	w := tabwriter.NewWriter(os.Stderr, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tEXIT\tERROR")
+	for i := range results {
+		r := &results[i]
+		msg := ""
+		if r.err != nil {
+			r.code = exitCodeOf(r.err)
+			msg = errorMessage(r.err, r.host)
+		}
+		code = max(code, r.code)
+		fmt.Fprintf(w, "%s\t%d\t%s\n", r.host, r.code, msg)
+	}
+	return code, w.Flush()
+}
+
+// runOnHost runs the command on host, and prefixes its output with the host.
+func runOnHost(ctx context.Context, arguments docopt.Opts, host string, mutex *sync.Mutex,
+	policy client.RetryPolicy, connectTimeout time.Duration, dialOpts []grpc.DialOption) (int, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "host", trace.WithAttributes(attribute.String("address", host)))
+	defer span.End()
+	conn, err := dial(ctx, host, connectTimeout, dialOpts...)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	rceClient := client.New(conn)
+	rceClient.Retry = policy
+	cmd := newCmd(rceClient, arguments)
+	if err = prepareCmd(cmd, arguments); err != nil {
+		return 0, err
+	}
+	prefix := []byte("[" + host + "] ")
+	stdout := &prefixWriter{w: os.Stdout, mutex: mutex, prefix: prefix}
+	stderr := &prefixWriter{w: os.Stderr, mutex: mutex, prefix: prefix}
+	defer func() {
+		_ = stdout.Flush()
+		_ = stderr.Flush()
+	}()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.OnQueued = func(position uint32) {
+		fmt.Fprintf(stderr, "rce_client: Queued at position %d\n", position)
+	}
+	cmd.OnGap = func(stdoutBytes, stderrBytes uint64) {
+		fmt.Fprintf(stderr, "[rce: output truncated, %d bytes of stdout and %d bytes of stderr dropped]\n",
+			stdoutBytes, stderrBytes)
+	}
+	result, err := cmd.Run(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return resultExitCode(result), nil
+}
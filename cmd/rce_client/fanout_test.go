@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"github.com/docopt/docopt-go"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	var mutex sync.Mutex
+	w := &prefixWriter{w: &out, mutex: &mutex, prefix: []byte("[a] ")}
+	for _, s := range []string{"hel", "lo\nwor", "ld\n\nno newline"} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out.String() != "[a] hello\n[a] world\n[a] \n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "[a] hello\n[a] world\n[a] \n[a] no newline\n" {
+		t.Fatalf("unexpected output %q", out.String())
+	}
+
+	out.Reset()
+	_, _ = w.Write([]byte(strings.Repeat("x", maxLineSize)))
+	if out.Len() != len("[a] ")+maxLineSize+1 {
+		t.Fatalf("expect a long line to be written, got %d bytes", out.Len())
+	}
+}
+
+func TestReadHosts(t *testing.T) {
+	hosts, err := readHosts(docopt.Opts{"--hosts": "a:8999, b:8999,,"})
+	if err != nil || !reflect.DeepEqual(hosts, []string{"a:8999", "b:8999"}) {
+		t.Fatalf("unexpected hosts %v, err %v", hosts, err)
+	}
+	file := path.Join(t.TempDir(), "hosts")
+	err = os.WriteFile(file, []byte("# servers\na:8999\n\n  b:8999\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts, err = readHosts(docopt.Opts{"--hosts-file": file})
+	if err != nil || !reflect.DeepEqual(hosts, []string{"a:8999", "b:8999"}) {
+		t.Fatalf("unexpected hosts %v, err %v", hosts, err)
+	}
+	if _, err = readHosts(docopt.Opts{"--hosts": " , "}); exitCodeOf(err) != exitUsage {
+		t.Fatalf("expect usage error, got %v", err)
+	}
+}
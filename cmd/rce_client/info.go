@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/reyoung/rce/client"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+func limitString(n uint32) string {
+	if n == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(n)
+}
+
+// doInfo prints the information of the server.
+func doInfo(ctx context.Context, rceClient *client.Client) error {
+	rsp, err := rceClient.Info(ctx)
+	if err != nil {
+		return err
+	}
+	var features []string
+	for _, f := range rsp.Features {
+		features = append(features, client.FeatureName(f))
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Version:\t%s\n", rsp.Version)
+	fmt.Fprintf(w, "OS/Arch:\t%s/%s\n", rsp.Os, rsp.Arch)
+	fmt.Fprintf(w, "Hostname:\t%s\n", rsp.Hostname)
+	fmt.Fprintf(w, "Shells:\t%s\n", strings.Join(rsp.Shells, ", "))
+	fmt.Fprintf(w, "Features:\t%s\n", strings.Join(features, ", "))
+	fmt.Fprintf(w, "Compressors:\t%s\n", strings.Join(rsp.Compressors, ", "))
+	fmt.Fprintf(w, "Max running:\t%s, %s per caller\n",
+		limitString(rsp.Limits.GetMaxRunning()), limitString(rsp.Limits.GetMaxRunningPerCaller()))
+	fmt.Fprintf(w, "Output buffer:\t%d bytes, max %d bytes\n",
+		rsp.Limits.GetOutputBufferSize(), rsp.Limits.GetOutputBufferMax())
+	fmt.Fprintf(w, "Load:\t%d running, %d queued, load average %.2f\n",
+		rsp.Load.GetRunning(), rsp.Load.GetQueued(), rsp.Load.GetLoadAverage())
+	fmt.Fprintf(w, "Draining:\t%t\n", rsp.Load.GetDraining())
+	return w.Flush()
+}
@@ -2,28 +2,47 @@ package main
 
 import (
 	"context"
-	"emperror.dev/emperror"
 	"fmt"
 	"github.com/creack/pty"
 	"github.com/docopt/docopt-go"
+	"github.com/reyoung/rce/client"
+	"github.com/reyoung/rce/compression"
 	"github.com/reyoung/rce/protocol"
+	"github.com/reyoung/rce/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/term"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
-	"io"
+	"google.golang.org/grpc/keepalive"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
-	"sync"
 	"syscall"
+	"time"
 )
 
 const docs = `Remote Code Executor Client
 
 Usage:
-    rce_client [--with-stdin] [--env=<e>]... [--pid-file=<p>]
-        [--upload=<u>]... [--dir=<dir>] --address=<a> -- <command> [<args>]...
+    rce_client [--with-stdin] [--escape-char=<c>] [--env=<e>]... [--pid-file=<p>]
+        [--upload=<u>]... [--archive-compression=<c>] [--cache] [--preserve-owner] [--compression=<c>]
+        [--output-buffer=<n>] [--output-overflow=<p>] [--timestamps] [--merge-stderr] [--separate-stderr]
+        [--priority=<n>] [--dir=<dir>] [--otlp-endpoint=<e>] [--retries=<n>] [--retry-backoff=<t>]
+        [--connect-timeout=<t>] [--keepalive=<t>] --address=<a> -- <command> [<args>]...
+    rce_client fanout (--hosts=<h> | --hosts-file=<f>) [--parallel=<n>] [--env=<e>]...
+        [--upload=<u>]... [--archive-compression=<c>] [--cache] [--preserve-owner] [--compression=<c>]
+        [--output-buffer=<n>] [--output-overflow=<p>] [--timestamps] [--merge-stderr]
+        [--priority=<n>] [--dir=<dir>] [--otlp-endpoint=<e>] [--retries=<n>] [--retry-backoff=<t>]
+        [--connect-timeout=<t>] [--keepalive=<t>] -- <command> [<args>]...
+    rce_client drain [--wait] [--timeout=<t>] [--compression=<c>] [--otlp-endpoint=<e>]
+        [--connect-timeout=<t>] [--keepalive=<t>] --address=<a>
+    rce_client info [--compression=<c>] [--otlp-endpoint=<e>] [--connect-timeout=<t>] [--keepalive=<t>] --address=<a>
     rce_client -h | --help
     rce_client --version
 
@@ -31,210 +50,424 @@ Options:
     -h --help                 Show this screen.
     --version                 Show version.
     --upload=<u>              Upload local file to remote. format are "local_path:remote_path".
+                              Directories are uploaded as an archive.
+    --archive-compression=<c>  Compression of uploaded archives, none, gzip or zstd [default: zstd].
+    --cache                   Upload files through the server side file cache, only files missing
+                              in the cache are sent. Empty directories and metadata of
+                              directories are not uploaded.
+    --preserve-owner          Preserve the owner of uploaded files, the server must run as root.
+    --compression=<c>         Compression of the stream, none, gzip or zstd [default: none].
+    --output-buffer=<n>       Bytes of the server side output buffer, 0 uses the server default [default: 0].
+    --output-overflow=<p>     Policy when the output buffer is full, block, drop-oldest or spill.
+                              block stalls the command, drop-oldest drops the oldest output, and
+                              spill writes the output to disk on the server [default: block].
+    --timestamps              Prefix each line of output with the time when the server captured it.
+    --merge-stderr            Redirect stderr of the command to stdout, like 2>&1.
+    --separate-stderr         Keep stderr of the command separated from the terminal when the
+                              remote command runs in a pty.
+    --priority=<n>            Priority of the job in the server queue, higher starts first [default: 0].
+    --wait                    Wait for the running jobs to end when draining the server.
+    --timeout=<t>             Max time to wait, e.g. 10m [default: 1h].
     --dir=<dir>               Remote working directory, empty use temp dir.
     --address=<a>             Remote server address.
+    --hosts=<h>               Comma separated addresses of the servers to run the command on. Each line
+                              of output is prefixed with the address of its server.
+    --hosts-file=<f>          File of the addresses of the servers, one per line. Empty lines and
+                              lines starting with # are ignored.
+    --parallel=<n>            Max servers to run the command on concurrently [default: 32].
+    --retries=<n>             Max retries of the job if the server is unavailable before the command
+                              starts. The command is never run again once it may have started [default: 3].
+    --retry-backoff=<t>       Initial wait between retries and reconnections, which doubles up to 5s [default: 200ms].
+    --connect-timeout=<t>     Max time to retry connecting to the server [default: 10s].
+    --keepalive=<t>           Interval of keepalive pings of an idle connection, which must not be shorter than
+                              the keepalive min time of the server, 0 disables keepalive [default: 5m].
+    --otlp-endpoint=<e>       Export traces to the OTLP/gRPC collector, e.g. http://localhost:4317.
     --env=<e>                 Environment variables. format are "key=value".
-    --with-stdin              With stdin.
+    --with-stdin              With stdin. If stdin is a terminal, the remote command runs in a pty.
+    --escape-char=<c>         Escape character of the terminal, none disables escapes [default: ~].
     --pid-file=<p>            Pid file.
     <command>                 Command to run.
     <args>                    Arguments of command.
+
+Escape sequences:
+    If stdin is a terminal, the escape character following a newline starts an
+    escape sequence, like ssh.
+    ~.      disconnect, the remote command is killed and rce_client exits with 129.
+    ~^Z     suspend rce_client.
+    ~?      list the escape sequences.
+    ~~      send the escape character.
+
+Exit status:
+    rce_client exits with the exit status of the remote command, or 128+N if
+    the remote command is killed by signal N. Failures of rce_client exit with
+    64      invalid arguments.
+    66      a local file can not be read or written, e.g. an uploaded file.
+    69      the server is unavailable, it can not be connected or is draining. The
+            command did not run, it is safe to run it again.
+    70      the server rejected or failed the job, e.g. a feature is not supported.
+    76      protocol error, e.g. the connection is lost after the command started.
+            The command may have run.
+    128+N   rce_client is interrupted by signal N, the remote command is killed.
+    fanout prints the exit status of each server at the end, and exits with 0
+    if the command succeeded on all servers, otherwise with the highest exit
+    status of the servers.
 `
 
-func panic2[T any](v T, err error) T {
+// helpHandler prints the usage. Invalid arguments exit with exitUsage.
+func helpHandler(err error, usage string) {
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(exitUsage)
 	}
-	return v
+	fmt.Println(usage)
+	os.Exit(0)
+}
+
+// newCmd returns the command of the arguments.
+func newCmd(rceClient *client.Client, arguments docopt.Opts) *client.Cmd {
+	return rceClient.Command(arguments["<command>"].(string), arguments["<args>"].([]string)...)
 }
 
-func prepareHeadFrame(arguments docopt.Opts) (h *protocol.SpawnRequest_Head) {
-	h = &protocol.SpawnRequest_Head{}
-	envs := arguments["--env"].([]string)
-	for _, env := range envs {
-		kvs := strings.SplitN(env, "=", 2)
-		if len(kvs) != 2 {
-			panic(fmt.Sprintf("invalid env, %s", env))
+// prepareCmd sets up cmd with the arguments.
+func prepareCmd(cmd *client.Cmd, arguments docopt.Opts) error {
+	cmd.Env = arguments["--env"].([]string)
+	for _, env := range cmd.Env {
+		if !strings.Contains(env, "=") {
+			return usageErrorf("invalid env %s, expect key=value", env)
 		}
-		h.Envs = append(h.Envs, &protocol.SpawnRequest_Head_Env{
-			Key:   kvs[0],
-			Value: kvs[1],
-		})
 	}
-
-	dirIface := arguments["--dir"]
-	if dirIface != nil {
-		dir := dirIface.(string)
-		h.Path = dir
+	if dir, ok := arguments["--dir"].(string); ok {
+		cmd.Dir = dir
 	}
-
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
 	if arguments["--with-stdin"].(bool) {
-		h.HasStdin = true
+		cmd.Stdin = os.Stdin
 	}
+	for _, u := range arguments["--upload"].([]string) {
+		local, remote, ok := strings.Cut(u, ":")
+		if !ok {
+			return usageErrorf("invalid upload %s, expect local_path:remote_path", u)
+		}
+		cmd.Files = append(cmd.Files, client.File{Local: local, Remote: remote})
+	}
+	cmd.Cache = arguments["--cache"].(bool)
+	var err error
+	cmd.ArchiveCompression, err = parseArchiveCompression(arguments["--archive-compression"].(string))
+	if err != nil {
+		return err
+	}
+	cmd.PreserveOwner = arguments["--preserve-owner"].(bool)
+	cmd.MergeStderr = arguments["--merge-stderr"].(bool)
+	priority, err := strconv.ParseInt(arguments["--priority"].(string), 10, 32)
+	if err != nil {
+		return usageErrorf("invalid priority %s", arguments["--priority"])
+	}
+	cmd.Priority = int32(priority)
+	size, err := strconv.ParseUint(arguments["--output-buffer"].(string), 10, 64)
+	if err != nil {
+		return usageErrorf("invalid output buffer %s", arguments["--output-buffer"])
+	}
+	policy, err := parseOverflowPolicy(arguments["--output-overflow"].(string))
+	if err != nil {
+		return err
+	}
+	cmd.OutputBuffer = &protocol.SpawnRequest_Head_OutputBuffer{Size: size, Policy: policy}
+	cmd.Timestamps = arguments["--timestamps"].(bool)
 
-	command := arguments["<command>"].(string)
-	h.Command = command
-	h.Args = arguments["<args>"].([]string)
-
-	if term.IsTerminal(0) && h.HasStdin {
+	if term.IsTerminal(0) && cmd.Stdin != nil {
 		rows, cols, err := pty.Getsize(os.Stdin)
 		if err != nil {
-			panic(fmt.Errorf("failed to get terminal size: %w", err))
+			return fmt.Errorf("failed to get terminal size: %w", err)
 		}
-		h.WindowSize = &protocol.WindowSize{
+		cmd.Pty = &protocol.WindowSize{
 			Row: uint32(rows),
 			Col: uint32(cols),
 		}
-		h.AllocatePty = true
+		cmd.SeparateStderr = arguments["--separate-stderr"].(bool)
 	}
 
-	return h
+	cmd.OnQueued = func(position uint32) {
+		log.Printf("Queued at position %d", position)
+	}
+	cmd.OnGap = func(stdoutBytes, stderrBytes uint64) {
+		fmt.Fprintf(os.Stderr, "\r\n[rce: output truncated, %d bytes of stdout and %d bytes of stderr dropped]\r\n",
+			stdoutBytes, stderrBytes)
+	}
+	return nil
 }
 
-const sendBufSize = 4096
+func parseOverflowPolicy(p string) (protocol.SpawnRequest_Head_OutputBuffer_OverflowPolicy, error) {
+	v, ok := protocol.SpawnRequest_Head_OutputBuffer_OverflowPolicy_value[strings.ReplaceAll(strings.ToUpper(p), "-", "_")]
+	if !ok {
+		return 0, usageErrorf("invalid output overflow policy %s", p)
+	}
+	return protocol.SpawnRequest_Head_OutputBuffer_OverflowPolicy(v), nil
+}
 
-func doUploadFile(local, remote string, client protocol.RemoteCodeExecutor_SpawnClient, executable bool) {
-	f := panic2(os.Open(local))
+func parseArchiveCompression(c string) (protocol.SpawnRequest_Archive_Compression, error) {
+	v, ok := protocol.SpawnRequest_Archive_Compression_value[strings.ToUpper(c)]
+	if !ok {
+		return 0, usageErrorf("invalid archive compression %s", c)
+	}
+	return protocol.SpawnRequest_Archive_Compression(v), nil
+}
 
-	var buf [sendBufSize]byte
-	trun := true
-	for {
-		n, err := f.Read(buf[:])
+// doRCE runs cmd and returns the exit code of the remote command.
+func doRCE(ctx context.Context, arguments docopt.Opts, cmd *client.Cmd) (int, error) {
+	err := cmd.Start(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if pidFile, ok := arguments["--pid-file"].(string); ok {
+		err = os.WriteFile(pidFile, []byte(cmd.PID()), 0600)
 		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			panic(err)
+			return 0, fmt.Errorf("failed to write pid file: %w", err)
 		}
-		emperror.Panic(client.Send(&protocol.SpawnRequest{Payload: &protocol.SpawnRequest_File_{
-			File: &protocol.SpawnRequest_File{
-				Filename:   remote,
-				Content:    buf[:n],
-				Executable: executable,
-				Truncate:   trun,
-			},
-		}}))
-		trun = false
 	}
+	result, err := cmd.Wait()
+	if err != nil {
+		return 0, err
+	}
+	return resultExitCode(result), nil
 }
 
-func doUpload2(local, remote string, client protocol.RemoteCodeExecutor_SpawnClient) {
-	fs := panic2(os.Stat(local))
-	if fs.IsDir() {
-		panic(fmt.Sprintf("upload dir is not supported now, %s", local))
+// doDrain stops the server from accepting new jobs.
+func doDrain(ctx context.Context, arguments docopt.Opts, rceClient *client.Client) error {
+	timeout, err := durationArgument(arguments, "--timeout")
+	if err != nil {
+		return err
 	}
-
-	doUploadFile(local, remote, client, fs.Mode()&0100 != 0)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	running, err := rceClient.Drain(ctx, arguments["--wait"].(bool))
+	if err != nil {
+		return fmt.Errorf("failed to drain, %d jobs are running: %w", running, err)
+	}
+	log.Printf("Drained, %d jobs are running", running)
+	return nil
 }
 
-func doUpload(uploads []string, client protocol.RemoteCodeExecutor_SpawnClient) {
-	for _, u := range uploads {
-		us := strings.SplitN(u, ":", 2)
-		if len(us) != 2 {
-			panic(fmt.Sprintf("invalid upload, %s", u))
+// dial connects to addr. The connection is established eagerly, so the
+// time of dialing is traced. It retries connecting until timeout, then the
+// calls fail with the error of the connection.
+func dial(ctx context.Context, addr string, timeout time.Duration, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "dial", trace.WithAttributes(attribute.String("address", addr)))
+	defer span.End()
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return nil, usageErrorf("invalid address %s: %w", addr, err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		if state == connectivity.Ready || !conn.WaitForStateChange(ctx, state) {
+			span.SetAttributes(attribute.String("state", state.String()))
+			return conn, nil
 		}
-		doUpload2(us[0], us[1], client)
 	}
 }
 
-func doRCE(arguments docopt.Opts, rceClient protocol.RemoteCodeExecutorClient, pid *string) int {
-	cli := panic2(rceClient.Spawn(context.Background()))
-	emperror.Panic(cli.Send(&protocol.SpawnRequest{
-		Payload: &protocol.SpawnRequest_Head_{Head: prepareHeadFrame(arguments)}}))
-	doUpload(arguments["--upload"].([]string), cli)
-	emperror.Panic(cli.Send(&protocol.SpawnRequest{
-		Payload: &protocol.SpawnRequest_Start_{Start: &protocol.SpawnRequest_Start{}}}))
+// maxBackoff is the max wait between retries and reconnections.
+const maxBackoff = 5 * time.Second
 
-	var complete sync.WaitGroup
+func durationArgument(arguments docopt.Opts, name string) (time.Duration, error) {
+	d, err := time.ParseDuration(arguments[name].(string))
+	if err != nil || d < 0 {
+		return 0, usageErrorf("invalid %s %s", strings.TrimPrefix(name, "--"), arguments[name])
+	}
+	return d, nil
+}
 
-	if arguments["--with-stdin"].(bool) {
-		complete.Add(1)
-		go func() {
-			defer complete.Done()
-			// read stdin and send to remote
-			var buf [sendBufSize]byte
-			for {
-				n, err := os.Stdin.Read(buf[:])
-				if err != nil {
-					if err == io.EOF {
-						emperror.Panic(cli.Send(&protocol.SpawnRequest{
-							Payload: &protocol.SpawnRequest_Stdin_{Stdin: &protocol.SpawnRequest_Stdin{
-								Eof: true,
-							}}}))
-						break
-					}
-					panic(err)
-				}
-				emperror.Panic(cli.Send(&protocol.SpawnRequest{
-					Payload: &protocol.SpawnRequest_Stdin_{Stdin: &protocol.SpawnRequest_Stdin{
-						Stdin: buf[:n],
-					}}}))
-			}
-		}()
+// connectOptions returns the dial options of the connection arguments and
+// the time to retry connecting.
+func connectOptions(arguments docopt.Opts) (opts []grpc.DialOption, timeout time.Duration, err error) {
+	baseDelay, err := durationArgument(arguments, "--retry-backoff")
+	if err != nil {
+		return nil, 0, err
 	}
+	timeout, err = durationArgument(arguments, "--connect-timeout")
+	if err != nil {
+		return nil, 0, err
+	}
+	keepaliveTime, err := durationArgument(arguments, "--keepalive")
+	if err != nil {
+		return nil, 0, err
+	}
+	opts = append(opts, grpc.WithConnectParams(grpc.ConnectParams{
+		Backoff:           backoff.Config{BaseDelay: baseDelay, Multiplier: 2, Jitter: 0.2, MaxDelay: maxBackoff},
+		MinConnectTimeout: 20 * time.Second,
+	}))
+	if keepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    keepaliveTime,
+			Timeout: 20 * time.Second,
+		}))
+	}
+	return opts, timeout, nil
+}
 
-	for {
-		rsp, err := cli.Recv()
-		if err != nil {
-			if err == io.EOF {
-				return -1
-			}
-			panic(err)
-		}
-		if *pid == "" {
-			*pid = rsp.GetPid().GetId()
+// retryPolicy returns the retry policy of the job.
+func retryPolicy(arguments docopt.Opts) (client.RetryPolicy, error) {
+	retries, err := strconv.Atoi(arguments["--retries"].(string))
+	if err != nil || retries < 0 {
+		return client.RetryPolicy{}, usageErrorf("invalid retries %s", arguments["--retries"])
+	}
+	initialBackoff, err := durationArgument(arguments, "--retry-backoff")
+	if err != nil {
+		return client.RetryPolicy{}, err
+	}
+	return client.RetryPolicy{MaxAttempts: retries + 1, InitialBackoff: initialBackoff, MaxBackoff: maxBackoff}, nil
+}
 
-			if *pid != "" && arguments["--pid-file"] != nil {
-				emperror.Panic(os.WriteFile(arguments["--pid-file"].(string), []byte(*pid), 0600))
-			}
+// setupTracing exports the traces of the client if --otlp-endpoint is set.
+// The returned flush must be called before exit.
+func setupTracing(arguments docopt.Opts) (flush func(), dialOpts []grpc.DialOption, err error) {
+	endpoint, _ := arguments["--otlp-endpoint"].(string)
+	if endpoint == "" {
+		return func() {}, nil, nil
+	}
+	shutdown, err := tracing.Setup(context.Background(), "rce_client", endpoint)
+	if err != nil {
+		return nil, nil, usageErrorf("invalid otlp endpoint %s: %w", endpoint, err)
+	}
+	flush = func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			log.Printf("Failed to export traces: %v", err)
 		}
-		if rsp.GetError() != nil {
-			panic(rsp.GetError().Error)
+	}
+	return flush, []grpc.DialOption{grpc.WithStatsHandler(otelgrpc.NewClientHandler())}, nil
+}
+
+// run runs the command of the arguments and returns the exit code.
+func run(arguments docopt.Opts) (code int) {
+	addr, _ := arguments["--address"].(string) // not set in fanout.
+	fail := func(err error) int {
+		log.Print(errorMessage(err, addr))
+		return exitCodeOf(err)
+	}
+	flushTraces, dialOpts, err := setupTracing(arguments)
+	if err != nil {
+		return fail(err)
+	}
+	ctx, span := tracing.Tracer().Start(context.Background(), "rce_client")
+	defer func() {
+		span.SetAttributes(attribute.Int("exit_code", code))
+		span.End()
+		flushTraces()
+	}()
+
+	connectOpts, connectTimeout, err := connectOptions(arguments)
+	if err != nil {
+		return fail(err)
+	}
+	dialOpts = append(dialOpts, connectOpts...)
+	dialOpts = append(dialOpts, grpc.WithCredentialsBundle(insecure.NewBundle()))
+	if c := arguments["--compression"].(string); c != compression.None {
+		err = compression.Register(c)
+		if err != nil {
+			return fail(usageError{err})
 		}
-		if rsp.GetExit() != nil {
-			return int(rsp.GetExit().Code)
+		dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(grpc.UseCompressor(c)))
+	}
+	if arguments["fanout"].(bool) {
+		code, err := interruptible(ctx, nil, func(ctx context.Context) (int, error) {
+			return doFanout(ctx, arguments, connectTimeout, dialOpts)
+		})
+		if err != nil {
+			return fail(err)
 		}
-		if rsp.GetStdout() != nil {
-			os.Stdout.Write(rsp.GetStdout().Stdout)
+		return code
+	}
+	conn, err := dial(ctx, addr, connectTimeout, dialOpts...)
+	if err != nil {
+		return fail(err)
+	}
+	defer conn.Close()
+	rceClient := client.New(conn)
+	if arguments["drain"].(bool) {
+		if err = doDrain(ctx, arguments, rceClient); err != nil {
+			return fail(err)
 		}
-		if rsp.GetStderr() != nil {
-			os.Stderr.Write(rsp.GetStderr().Stderr)
+		return 0
+	}
+	if arguments["info"].(bool) {
+		if err = doInfo(ctx, rceClient); err != nil {
+			return fail(err)
 		}
+		return 0
+	}
+	rceClient.Retry, err = retryPolicy(arguments)
+	if err != nil {
+		return fail(err)
+	}
+	cmd := newCmd(rceClient, arguments)
+	if err = prepareCmd(cmd, arguments); err != nil {
+		return fail(err)
+	}
+	tty, disconnected, err := setupTerminal(cmd, arguments)
+	if err != nil {
+		return fail(err)
 	}
+	defer tty.Restore()
+	code, err = interruptible(ctx, disconnected, func(ctx context.Context) (int, error) {
+		defer tty.Restore() // before the error is logged, and on panics.
+		return doRCE(ctx, arguments, cmd)
+	})
+	if err != nil {
+		return fail(err)
+	}
+	return code
 }
 
-func main() {
-	arguments, _ := docopt.ParseArgs(docs, nil, "Remote Code Executor Client 1.0")
-	allocateTTY := false
-	if arguments["--with-stdin"].(bool) {
-		if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
-			allocateTTY = true
-			panic2(term.MakeRaw(fd))
-		}
+// interruptible runs fn and returns its result. If rce_client receives
+// SIGINT, SIGTERM or SIGHUP, the context of fn is cancelled, which kills the
+// remote commands, and 128+N is returned once fn returns. Closing
+// disconnected is handled like SIGHUP.
+func interruptible(ctx context.Context, disconnected <-chan struct{},
+	fn func(ctx context.Context) (int, error)) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigChan)
+	type result struct {
+		code int
+		err  error
 	}
-	addr := arguments["--address"].(string)
-	client := panic2(grpc.NewClient(addr, grpc.WithCredentialsBundle(insecure.NewBundle())))
-	defer client.Close()
-	rceClient := protocol.NewRemoteCodeExecutorClient(client)
-	pid := ""
-	defer func() {
-		if pid != "" {
-			log.Printf("Killing pid %s", pid)
-			_, _ = rceClient.Kill(context.Background(), &protocol.PID{Id: pid})
-		}
+	done := make(chan result, 1)
+	go func() {
+		code, err := fn(ctx)
+		done <- result{code, err}
 	}()
-
-	fn := func() {
-		errCode := doRCE(arguments, rceClient, &pid)
-		os.Exit(errCode)
+	select {
+	case r := <-done:
+		return r.code, r.err
+	case sig := <-sigChan:
+		cancel()
+		<-done
+		log.Printf("Received %s, killed the remote command", sig)
+		return exitSignal + int(sig.(syscall.Signal)), nil
+	case <-disconnected:
+		cancel()
+		<-done
+		log.Print("Disconnected, killed the remote command")
+		return exitSignal + int(syscall.SIGHUP), nil
 	}
+}
 
-	if !allocateTTY {
-		go fn()
-		// wait for signal
-		sigChan := make(chan os.Signal, 1)
-		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-		<-sigChan
-	} else {
-		fn()
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("rce_client: ")
+	parser := &docopt.Parser{HelpHandler: helpHandler}
+	arguments, err := parser.ParseArgs(docs, nil, "Remote Code Executor Client 1.0")
+	if err != nil {
+		os.Exit(exitUsage)
 	}
+	// run returns instead of exiting, so the terminal is restored.
+	os.Exit(run(arguments))
 }
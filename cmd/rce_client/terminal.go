@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"github.com/docopt/docopt-go"
+	"github.com/reyoung/rce/client"
+	"golang.org/x/term"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// terminal keeps the terminal of stdin in raw mode while the remote command
+// runs in a pty.
+type terminal struct {
+	fd    int
+	mutex sync.Mutex
+	state *term.State // nil if the terminal is restored.
+}
+
+func makeRaw(fd int) (*terminal, error) {
+	t := &terminal{fd: fd}
+	return t, t.makeRaw()
+}
+
+func (t *terminal) makeRaw() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	state, err := term.MakeRaw(t.fd)
+	if err != nil {
+		return &fs.PathError{Op: "make raw", Path: os.Stdin.Name(), Err: err}
+	}
+	t.state = state
+	return nil
+}
+
+// Restore restores the terminal. It is safe to call Restore many times.
+func (t *terminal) Restore() {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.state != nil {
+		_ = term.Restore(t.fd, t.state)
+		t.state = nil
+	}
+}
+
+// Suspend restores the terminal and stops rce_client like ^Z in a shell.
+// The terminal is raw again when rce_client is continued.
+func (t *terminal) Suspend() error {
+	t.Restore()
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTSTP); err != nil {
+		return err
+	}
+	return t.makeRaw()
+}
+
+// setupTerminal makes the terminal raw if cmd runs in a pty, and handles the
+// escape sequences of stdin. The returned channel is closed by the escape
+// sequence ~.; both are nil if stdin is not a terminal.
+func setupTerminal(cmd *client.Cmd, arguments docopt.Opts) (*terminal, <-chan struct{}, error) {
+	escape, err := escapeChar(arguments)
+	if err != nil || cmd.Pty == nil {
+		return nil, nil, err
+	}
+	tty, err := makeRaw(int(os.Stdin.Fd()))
+	if err != nil || escape == 0 {
+		return tty, nil, err
+	}
+	r := newEscapeReader(cmd.Stdin, escape, tty)
+	cmd.Stdin = r
+	return tty, r.disconnected, nil
+}
+
+// escapeHelp is printed by the escape sequence ~?.
+const escapeHelp = "Supported escape sequences:\r\n" +
+	"  %[1]c.   - disconnect, the remote command is killed\r\n" +
+	"  %[1]c^Z  - suspend rce_client\r\n" +
+	"  %[1]c?   - this message\r\n" +
+	"  %[1]c%[1]c   - send the escape character\r\n" +
+	"(Note that escapes are only recognized immediately after newline.)\r\n"
+
+// escapeChar returns the escape character of --escape-char, 0 if escapes are
+// disabled.
+func escapeChar(arguments docopt.Opts) (byte, error) {
+	c := arguments["--escape-char"].(string)
+	switch {
+	case c == "none":
+		return 0, nil
+	case len(c) == 1 && c[0] >= ' ' && c[0] < 0x7f:
+		return c[0], nil
+	}
+	return 0, usageErrorf("invalid escape char %s", c)
+}
+
+// escapeReader handles the escape sequences in stdin of a terminal like ssh.
+// An escape sequence is the escape character following a newline and a
+// command character, which is not sent to the remote command.
+type escapeReader struct {
+	r      io.Reader
+	escape byte
+	tty    *terminal
+	// disconnected is closed by the escape sequence ~.
+	disconnected chan struct{}
+
+	afterNewline bool
+	escaped      bool   // the escape character is read.
+	buf          []byte // filtered input to be read.
+	err          error
+}
+
+func newEscapeReader(r io.Reader, escape byte, tty *terminal) *escapeReader {
+	return &escapeReader{
+		r:            r,
+		escape:       escape,
+		tty:          tty,
+		disconnected: make(chan struct{}),
+		afterNewline: true,
+	}
+}
+
+func (e *escapeReader) Read(p []byte) (int, error) {
+	for len(e.buf) == 0 && e.err == nil {
+		var n int
+		n, e.err = e.r.Read(p)
+		e.filter(p[:n])
+	}
+	if len(e.buf) == 0 {
+		return 0, e.err
+	}
+	n := copy(p, e.buf)
+	e.buf = e.buf[n:]
+	return n, nil
+}
+
+func (e *escapeReader) filter(input []byte) {
+	for _, b := range input {
+		if !e.escaped {
+			if e.afterNewline && b == e.escape {
+				e.escaped = true
+				continue
+			}
+			e.buf = append(e.buf, b)
+			e.afterNewline = b == '\r' || b == '\n'
+			continue
+		}
+		// like ssh, another escape sequence may follow a command character.
+		e.escaped = false
+		switch b {
+		case '.':
+			fmt.Fprintf(os.Stderr, "%c.\r\n", e.escape)
+			close(e.disconnected)
+			e.err = io.EOF
+			return
+		case 'Z' - '@': // ^Z
+			fmt.Fprintf(os.Stderr, "%c^Z [suspend rce_client]\r\n", e.escape)
+			if err := e.tty.Suspend(); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to suspend: %v\r\n", err)
+			}
+		case '?':
+			fmt.Fprintf(os.Stderr, escapeHelp, e.escape)
+		case e.escape:
+			e.buf = append(e.buf, b)
+			e.afterNewline = false
+		default:
+			e.buf = append(e.buf, e.escape, b)
+			e.afterNewline = b == '\r' || b == '\n'
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/docopt/docopt-go"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestEscapeReader(t *testing.T) {
+	for _, c := range []struct {
+		input, output string
+		disconnected  bool
+	}{
+		{"echo ~.\r", "echo ~.\r", false},
+		{"~~home\r~x", "~home\r~x", false},
+		{"ls\r~.ls\r", "ls\r", true},
+		{"a\n~", "a\n", false},
+		{"~?~.", "", true},
+	} {
+		// one byte at a time, so the escape sequences are split.
+		r := newEscapeReader(iotest.OneByteReader(strings.NewReader(c.input)), '~', nil)
+		output, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		disconnected := false
+		select {
+		case <-r.disconnected:
+			disconnected = true
+		default:
+		}
+		if string(output) != c.output || disconnected != c.disconnected {
+			t.Errorf("expect %q and disconnected %v of %q, got %q and %v",
+				c.output, c.disconnected, c.input, output, disconnected)
+		}
+	}
+}
+
+func TestEscapeChar(t *testing.T) {
+	for _, c := range []struct {
+		arg    string
+		escape byte
+		err    bool
+	}{
+		{"~", '~', false},
+		{"none", 0, false},
+		{"~~", 0, true},
+		{"\x1d", 0, true},
+	} {
+		escape, err := escapeChar(docopt.Opts{"--escape-char": c.arg})
+		if escape != c.escape || (err != nil) != c.err {
+			t.Errorf("unexpected escape %q, err %v of %q", escape, err, c.arg)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"github.com/reyoung/rce/config"
+	"github.com/reyoung/rce/server"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// loadConfig loads and validates the config from args. printConfig is set
+// by --print-config.
+func loadConfig(args []string, errorHandling flag.ErrorHandling) (cfg *config.Config, printConfig bool, err error) {
+	fs := flag.NewFlagSet(os.Args[0], errorHandling)
+	if errorHandling == flag.ContinueOnError {
+		fs.SetOutput(io.Discard)
+	}
+	fs.BoolVar(&printConfig, "print-config", false, "print the config in YAML and exit")
+	cfg, err = config.Load(fs, args)
+	if err != nil {
+		return nil, false, err
+	}
+	return cfg, printConfig, cfg.Validate()
+}
+
+// reload reloads the config on SIGHUP, and applies the settings which can
+// be changed at runtime.
+func reload(args []string, current *config.Config, rceServer *server.Server, level *slog.LevelVar) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+	for range sigChan {
+		cfg, _, err := loadConfig(args, flag.ContinueOnError)
+		if err != nil {
+			slog.Error("Failed to reload config", "err", err)
+			continue
+		}
+		if current.RequiresRestart(cfg) {
+			slog.Warn("Only the max running jobs, the output buffer and the log level are reloaded, " +
+				"restart the server to apply the other settings")
+		}
+		_ = level.UnmarshalText([]byte(cfg.Log.Level))
+		rceServer.Reload(cfg)
+		slog.Info("Config reloaded")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// listen listens on address. unix:///path and unix:path listen on a unix
+// socket with permission mode, others listen on tcp.
+func listen(address string, mode os.FileMode) (net.Listener, error) {
+	path, ok := strings.CutPrefix(address, "unix://")
+	if !ok {
+		path, ok = strings.CutPrefix(address, "unix:")
+	}
+	if !ok {
+		return net.Listen("tcp", address)
+	}
+	// the socket left by a crashed server is removed, unless it is in use.
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("socket %s is in use", path)
+		}
+		err = os.Remove(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove socket: %w", err)
+		}
+	}
+	// the socket is created in a private dir and moved into place, so it
+	// never exists with a wider permission than mode.
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".rce-socket")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create socket dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, "socket")
+	lis, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, err
+	}
+	ul := lis.(*net.UnixListener)
+	ul.SetUnlinkOnClose(false)
+	err = os.Chmod(tmp, mode)
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = lis.Close()
+		return nil, fmt.Errorf("failed to set permission of socket: %w", err)
+	}
+	return &unixListener{UnixListener: ul, path: path}, nil
+}
+
+// unixListener removes the socket at path when it is closed.
+type unixListener struct {
+	*net.UnixListener
+	path string
+}
+
+func (l *unixListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: l.path, Net: "unix"}
+}
+
+func (l *unixListener) Close() error {
+	err := l.UnixListener.Close()
+	_ = os.Remove(l.path)
+	return err
+}
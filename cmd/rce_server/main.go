@@ -1,30 +1,187 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
+	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/reyoung/rce/config"
+	"github.com/reyoung/rce/process"
 	"github.com/reyoung/rce/protocol"
 	"github.com/reyoung/rce/server"
+	"github.com/reyoung/rce/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
 	"log"
+	"log/slog"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
-var (
-	flagAddress = flag.String("address", ":8999", "grpc address")
-)
+// newLogger creates the logger of cfg, level can be changed on reload.
+func newLogger(cfg *config.LogConfig, level *slog.LevelVar) (*slog.Logger, error) {
+	err := level.UnmarshalText([]byte(cfg.Level))
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level %s: %w", cfg.Level, err)
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	switch cfg.Format {
+	case "text":
+		return slog.New(slog.NewTextHandler(os.Stderr, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %s", cfg.Format)
+	}
+}
+
+func serveMetrics(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	slog.Info("Metrics listening", "address", address)
+	err := http.ListenAndServe(address, mux)
+	if err != nil {
+		log.Fatalf("failed to serve metrics: %v", err)
+	}
+}
+
+// stopGrace is the time for clients to close their streams after their jobs
+// are killed, before the connections are closed.
+const stopGrace = 10 * time.Second
+
+// shutdown stops svr gracefully on SIGTERM or SIGINT.
+func shutdown(svr *grpc.Server, rceServer *server.Server, healthServer *health.Server, stopHealth func(),
+	timeout time.Duration) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-sigChan
+	slog.Info("Shutting down", "signal", sig.String())
+	stopHealth()
+	healthServer.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	rceServer.Shutdown(ctx)
+
+	timer := time.AfterFunc(stopGrace, svr.Stop)
+	defer timer.Stop()
+	svr.GracefulStop()
+}
 
 func main() {
-	flag.Parse()
+	cfg, printConfig, err := loadConfig(os.Args[1:], flag.ExitOnError)
+	if err != nil {
+		log.Fatalf("invalid config: %v", err)
+	}
+	if printConfig {
+		err = cfg.Write(os.Stdout)
+		if err != nil {
+			log.Fatalf("failed to print config: %v", err)
+		}
+		return
+	}
+
+	level := &slog.LevelVar{}
+	logger, err := newLogger(&cfg.Log, level)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	slog.SetDefault(logger)
+
+	err = process.EnableSubreaper()
+	if err != nil {
+		slog.Warn("Orphaned processes of jobs are not reaped by the server", "err", err)
+	}
+
+	socketMode, err := cfg.ParseSocketMode()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	var listeners []net.Listener
+	for _, address := range cfg.Addresses {
+		lis, err := listen(address, socketMode)
+		if err != nil {
+			log.Fatalf("failed to listen on %s: %v", address, err)
+		}
+		listeners = append(listeners, lis)
+	}
 
-	lis, err := net.Listen("tcp", *flagAddress)
+	rceServer, err := server.New(cfg, logger)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		log.Fatalf("%v", err)
 	}
+	go reload(os.Args[1:], cfg, rceServer, level)
 
-	svr := grpc.NewServer()
-	protocol.RegisterRemoteCodeExecutorServer(svr, &server.Server{})
-	log.Printf("server listening at %v\n", lis.Addr())
-	if err := svr.Serve(lis); err != nil {
-		log.Fatalf("failed to serve: %v", err)
+	// wait for handlers, so workspaces are cleaned before exit.
+	svrOpts := []grpc.ServerOption{
+		grpc.WaitForHandlers(true),
+		grpc.Creds(server.Credentials()),
+		grpc.KeepaliveParams(keepalive.ServerParameters{Time: cfg.Keepalive.Time, Timeout: cfg.Keepalive.Timeout}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.Keepalive.MinTime,
+			PermitWithoutStream: true,
+		}),
+	}
+	if cfg.OTLPEndpoint != "" {
+		shutdownTracing, err := tracing.Setup(context.Background(), "rce_server", cfg.OTLPEndpoint)
+		if err != nil {
+			log.Fatalf("failed to setup tracing: %v", err)
+		}
+		defer func() {
+			_ = shutdownTracing(context.Background())
+		}()
+		svrOpts = append(svrOpts, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	}
+	var grpcMetrics *grpcprom.ServerMetrics
+	if cfg.MetricsAddress != "" {
+		grpcMetrics = grpcprom.NewServerMetrics(grpcprom.WithServerHandlingTimeHistogram())
+		prometheus.MustRegister(grpcMetrics)
+		svrOpts = append(svrOpts,
+			grpc.ChainUnaryInterceptor(grpcMetrics.UnaryServerInterceptor()),
+			grpc.ChainStreamInterceptor(grpcMetrics.StreamServerInterceptor()))
+	}
+	// authorized after the metrics, so the denied calls are counted.
+	svrOpts = append(svrOpts,
+		grpc.ChainUnaryInterceptor(rceServer.UnaryInterceptor),
+		grpc.ChainStreamInterceptor(rceServer.StreamInterceptor))
+	svr := grpc.NewServer(svrOpts...)
+	protocol.RegisterRemoteCodeExecutorServer(svr, rceServer)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(svr, healthServer)
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	go rceServer.ServeHealth(healthCtx, healthServer, cfg.HealthInterval)
+	if cfg.Reflection {
+		reflection.Register(svr)
+	}
+	if grpcMetrics != nil {
+		grpcMetrics.InitializeMetrics(svr)
+		go serveMetrics(cfg.MetricsAddress)
+	}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		shutdown(svr, rceServer, healthServer, stopHealth, cfg.ShutdownTimeout)
+	}()
+	for _, lis := range listeners {
+		go func(lis net.Listener) {
+			slog.Info("Server listening", "address", lis.Addr().String())
+			// Serve fails if the server stops before it is called.
+			if err := svr.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+				log.Fatalf("failed to serve: %v", err)
+			}
+		}(lis)
 	}
+	<-stopped
+	slog.Info("Server stopped")
 }
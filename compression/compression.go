@@ -0,0 +1,153 @@
+// Package compression implements gRPC compressors for the RCE protocol.
+// Compressors are registered on demand, since gRPC advertises every
+// registered compressor to the peer.
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+	"io"
+	"sync"
+)
+
+const (
+	None = "none"
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+// Register registers the compressors of names to gRPC. None is ignored.
+func Register(names ...string) error {
+	for _, name := range names {
+		switch name {
+		case None:
+		case Gzip:
+			encoding.RegisterCompressor(newGzipCompressor())
+		case Zstd:
+			encoding.RegisterCompressor(newZstdCompressor())
+		default:
+			return fmt.Errorf("unknown compression %q", name)
+		}
+	}
+	return nil
+}
+
+type gzipCompressor struct {
+	writers sync.Pool
+	readers sync.Pool
+}
+
+func newGzipCompressor() *gzipCompressor {
+	return &gzipCompressor{writers: sync.Pool{New: func() any {
+		return gzip.NewWriter(io.Discard)
+	}}}
+}
+
+func (c *gzipCompressor) Name() string {
+	return Gzip
+}
+
+type gzipWriter struct {
+	*gzip.Writer
+	pool *sync.Pool
+}
+
+func (w *gzipWriter) Close() error {
+	defer w.pool.Put(w.Writer)
+	return w.Writer.Close()
+}
+
+func (c *gzipCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	gw := c.writers.Get().(*gzip.Writer)
+	gw.Reset(w)
+	return &gzipWriter{Writer: gw, pool: &c.writers}, nil
+}
+
+type gzipReader struct {
+	*gzip.Reader
+	pool *sync.Pool
+}
+
+func (r *gzipReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		r.pool.Put(r.Reader)
+	}
+	return n, err
+}
+
+func (c *gzipCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	gr, ok := c.readers.Get().(*gzip.Reader)
+	if !ok {
+		gr = &gzip.Reader{}
+	}
+	err := gr.Reset(r)
+	if err != nil {
+		c.readers.Put(gr)
+		return nil, err
+	}
+	return &gzipReader{Reader: gr, pool: &c.readers}, nil
+}
+
+// maxDecodedSize limits the memory of decoding a message, it is far larger
+// than the max message size of gRPC.
+const maxDecodedSize = 64 << 20
+
+type zstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func newZstdCompressor() *zstdCompressor {
+	// encoders with nil writer are only used by EncodeAll and DecodeAll,
+	// which are safe for concurrent use.
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		panic(err)
+	}
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecodedSize))
+	if err != nil {
+		panic(err)
+	}
+	return &zstdCompressor{encoder: encoder, decoder: decoder}
+}
+
+func (c *zstdCompressor) Name() string {
+	return Zstd
+}
+
+// zstdWriter buffers a whole gRPC message, and compresses it on Close.
+type zstdWriter struct {
+	c   *zstdCompressor
+	w   io.Writer
+	buf []byte
+}
+
+func (w *zstdWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+func (w *zstdWriter) Close() error {
+	_, err := w.w.Write(w.c.encoder.EncodeAll(w.buf, nil))
+	return err
+}
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return &zstdWriter{c: c, w: w}, nil
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	compressed, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := c.decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(buf), nil
+}
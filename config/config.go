@@ -0,0 +1,315 @@
+// Package config defines the configuration of rce_server. Settings are
+// read from a YAML or TOML file, the environment and the flags.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/reyoung/rce/compression"
+	"gopkg.in/yaml.v3"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix prefixes the environment variables of the flags, e.g.
+// RCE_MAX_RUNNING overrides --max-running.
+const EnvPrefix = "RCE_"
+
+type Config struct {
+	// Addresses to listen on, unix:///path listens on a unix socket.
+	Addresses []string `yaml:"addresses" toml:"addresses"`
+	// SocketMode is the octal permission of unix sockets.
+	SocketMode string `yaml:"socket_mode" toml:"socket_mode"`
+	// AllowedUsers are the names or uids of the local users allowed to call
+	// through unix sockets, empty allows all.
+	AllowedUsers        []string           `yaml:"allowed_users" toml:"allowed_users"`
+	Cache               CacheConfig        `yaml:"cache" toml:"cache"`
+	Compression         []string           `yaml:"compression" toml:"compression"`
+	OutputBuffer        OutputBufferConfig `yaml:"output_buffer" toml:"output_buffer"`
+	MaxRunning          int                `yaml:"max_running" toml:"max_running"`
+	MaxRunningPerCaller int                `yaml:"max_running_per_caller" toml:"max_running_per_caller"`
+	StateDir            string             `yaml:"state_dir" toml:"state_dir"`
+	MetricsAddress      string             `yaml:"metrics_address" toml:"metrics_address"`
+	ShutdownTimeout     time.Duration      `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	HealthInterval      time.Duration      `yaml:"health_interval" toml:"health_interval"`
+	Keepalive           KeepaliveConfig    `yaml:"keepalive" toml:"keepalive"`
+	Reflection          bool               `yaml:"reflection" toml:"reflection"`
+	OTLPEndpoint        string             `yaml:"otlp_endpoint" toml:"otlp_endpoint"`
+	Log                 LogConfig          `yaml:"log" toml:"log"`
+}
+
+type CacheConfig struct {
+	// Dir of the file cache, empty disables the file cache.
+	Dir      string `yaml:"dir" toml:"dir"`
+	Size     int64  `yaml:"size" toml:"size"`
+	Hardlink bool   `yaml:"hardlink" toml:"hardlink"`
+}
+
+type OutputBufferConfig struct {
+	Size int `yaml:"size" toml:"size"`
+	Max  int `yaml:"max" toml:"max"`
+}
+
+type KeepaliveConfig struct {
+	// Time after which the server pings an idle connection, so the jobs of
+	// dead clients are killed.
+	Time time.Duration `yaml:"time" toml:"time"`
+	// Timeout to wait for the ping ack before closing the connection.
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+	// MinTime is the min interval of client pings, clients pinging more
+	// often are disconnected.
+	MinTime time.Duration `yaml:"min_time" toml:"min_time"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level"`
+	Format string `yaml:"format" toml:"format"`
+}
+
+func Default() *Config {
+	return &Config{
+		Addresses:      []string{":8999"},
+		SocketMode:     "0660",
+		Cache:          CacheConfig{Size: 10 << 30},
+		Compression:    []string{compression.Zstd, compression.Gzip},
+		OutputBuffer:   OutputBufferConfig{Size: 1 << 20, Max: 64 << 20},
+		HealthInterval: time.Second,
+		Keepalive:      KeepaliveConfig{Time: time.Minute, Timeout: 20 * time.Second, MinTime: 10 * time.Second},
+		Log:            LogConfig{Level: "info", Format: "text"},
+	}
+}
+
+// stringList is a comma separated flag.
+type stringList struct {
+	list *[]string
+}
+
+func (l stringList) String() string {
+	if l.list == nil {
+		return ""
+	}
+	return strings.Join(*l.list, ",")
+}
+
+func (l stringList) Set(s string) error {
+	*l.list = nil
+	if s != "" {
+		*l.list = strings.Split(s, ",")
+	}
+	return nil
+}
+
+// RegisterFlags defines the flags of c in fs, with the values of c as the
+// defaults.
+func (c *Config) RegisterFlags(fs *flag.FlagSet) {
+	fs.Var(stringList{&c.Addresses}, "address",
+		"comma separated grpc addresses, unix:///path listens on a unix socket")
+	fs.StringVar(&c.SocketMode, "socket-mode", c.SocketMode, "octal permission of unix sockets")
+	fs.Var(stringList{&c.AllowedUsers}, "allowed-users",
+		"comma separated names or uids of the local users allowed to call through unix sockets, empty allows all")
+	fs.StringVar(&c.Cache.Dir, "cache-dir", c.Cache.Dir, "file cache dir, empty disables the file cache")
+	fs.Int64Var(&c.Cache.Size, "cache-size", c.Cache.Size, "max bytes of the file cache, <= 0 means unlimited")
+	fs.BoolVar(&c.Cache.Hardlink, "cache-hardlink", c.Cache.Hardlink,
+		"materialize cached files uploaded read-only and without owner by hard links, only if the jobs are trusted not to modify their files. "+
+			"A job can chmod and modify a linked file, which is found when the file is used again, "+
+			"but the jobs sharing the file before that see the modification")
+	fs.Var(stringList{&c.Compression}, "compression",
+		"compressors of responses in preference order, negotiated with the client, empty disables compression")
+	fs.IntVar(&c.OutputBuffer.Size, "output-buffer-size", c.OutputBuffer.Size,
+		"default bytes of the output buffer of a process")
+	fs.IntVar(&c.OutputBuffer.Max, "output-buffer-max", c.OutputBuffer.Max,
+		"max bytes of the output buffer which a client can request, and of the spill file of a process")
+	fs.IntVar(&c.MaxRunning, "max-running", c.MaxRunning, "max running jobs, others are queued, 0 means unlimited")
+	fs.IntVar(&c.MaxRunningPerCaller, "max-running-per-caller", c.MaxRunningPerCaller,
+		"max running jobs of a caller (remote host, or uid on unix sockets), 0 means unlimited")
+	fs.StringVar(&c.StateDir, "state-dir", c.StateDir,
+		"dir of the journal of jobs, which are cleaned on restart if the server crashed, empty disables the journal")
+	fs.StringVar(&c.MetricsAddress, "metrics-address", c.MetricsAddress,
+		"http address of prometheus metrics, empty disables metrics")
+	fs.DurationVar(&c.ShutdownTimeout, "shutdown-timeout", c.ShutdownTimeout,
+		"time to wait for running jobs on SIGTERM or SIGINT before killing them")
+	fs.DurationVar(&c.HealthInterval, "health-interval", c.HealthInterval,
+		"interval of updating the grpc health status")
+	fs.DurationVar(&c.Keepalive.Time, "keepalive-time", c.Keepalive.Time,
+		"time after which the server pings an idle connection")
+	fs.DurationVar(&c.Keepalive.Timeout, "keepalive-timeout", c.Keepalive.Timeout,
+		"time to wait for the ping ack before closing the connection")
+	fs.DurationVar(&c.Keepalive.MinTime, "keepalive-min-time", c.Keepalive.MinTime,
+		"min interval of client keepalive pings, clients pinging more often are disconnected")
+	fs.BoolVar(&c.Reflection, "reflection", c.Reflection, "register the grpc server reflection service")
+	fs.StringVar(&c.OTLPEndpoint, "otlp-endpoint", c.OTLPEndpoint,
+		"OTLP/gRPC collector of traces, e.g. http://localhost:4317, empty disables tracing")
+	fs.StringVar(&c.Log.Level, "log-level", c.Log.Level, "log level, one of debug, info, warn and error")
+	fs.StringVar(&c.Log.Format, "log-format", c.Log.Format, "log format, text or json")
+}
+
+// LoadFile reads the settings in filename into c. The format is TOML if the
+// extension is .toml, otherwise YAML. Settings missing in the file are kept.
+func (c *Config) LoadFile(filename string) error {
+	buf, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if filepath.Ext(filename) == ".toml" {
+		var md toml.MetaData
+		md, err = toml.Decode(string(buf), c)
+		if undecoded := md.Undecoded(); err == nil && len(undecoded) > 0 {
+			err = fmt.Errorf("unknown fields %v", undecoded)
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(buf))
+		dec.KnownFields(true)
+		err = dec.Decode(c)
+		if errors.Is(err, io.EOF) { // empty file
+			err = nil
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse config %s: %w", filename, err)
+	}
+	return nil
+}
+
+// envName returns the environment variable of flag name.
+func envName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// Load parses args with the flags of Config defined in fs. Settings are
+// overridden in the order of the defaults, the config file given by
+// --config, the environment variables and the flags. The config is not
+// validated.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	c := Default()
+	var filename string
+	fs.StringVar(&filename, "config", os.Getenv(envName("config")), "YAML or TOML config file")
+	c.RegisterFlags(fs)
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// the flags are applied again after the file and the environment.
+	explicit := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = f.Value.String()
+	})
+	if filename != "" {
+		err = c.LoadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" {
+			return
+		}
+		value, ok := explicit[f.Name]
+		if !ok {
+			value, ok = os.LookupEnv(envName(f.Name))
+		}
+		if !ok {
+			return
+		}
+		err := f.Value.Set(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %q of %s: %w", value, f.Name, err))
+		}
+	})
+	err = errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Validate checks the settings of c.
+func (c *Config) Validate() error {
+	var errs []error
+	if len(c.Addresses) == 0 {
+		errs = append(errs, errors.New("address is empty"))
+	}
+	if _, err := c.ParseSocketMode(); err != nil {
+		errs = append(errs, err)
+	}
+	for _, name := range c.Compression {
+		switch name {
+		case compression.None, compression.Gzip, compression.Zstd:
+		default:
+			errs = append(errs, fmt.Errorf("unknown compression %s", name))
+		}
+	}
+	if c.OutputBuffer.Size <= 0 || c.OutputBuffer.Max < c.OutputBuffer.Size {
+		errs = append(errs, fmt.Errorf("invalid output buffer size %d, max %d", c.OutputBuffer.Size, c.OutputBuffer.Max))
+	}
+	if c.MaxRunning < 0 || c.MaxRunningPerCaller < 0 {
+		errs = append(errs, fmt.Errorf("invalid max running %d, per caller %d", c.MaxRunning, c.MaxRunningPerCaller))
+	}
+	if c.ShutdownTimeout < 0 {
+		errs = append(errs, fmt.Errorf("invalid shutdown timeout %s", c.ShutdownTimeout))
+	}
+	if c.HealthInterval <= 0 {
+		errs = append(errs, fmt.Errorf("invalid health interval %s", c.HealthInterval))
+	}
+	if c.Keepalive.Time <= 0 || c.Keepalive.Timeout <= 0 || c.Keepalive.MinTime < 0 {
+		errs = append(errs, fmt.Errorf("invalid keepalive time %s, timeout %s, min time %s",
+			c.Keepalive.Time, c.Keepalive.Timeout, c.Keepalive.MinTime))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("invalid log level %s", c.Log.Level))
+	}
+	if c.Log.Format != "text" && c.Log.Format != "json" {
+		errs = append(errs, fmt.Errorf("invalid log format %s", c.Log.Format))
+	}
+	return errors.Join(errs...)
+}
+
+// ParseSocketMode returns the permission of unix sockets.
+func (c *Config) ParseSocketMode() (os.FileMode, error) {
+	mode, err := strconv.ParseUint(c.SocketMode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid socket mode %s", c.SocketMode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// Write writes c to w in YAML.
+func (c *Config) Write(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	err := enc.Encode(c)
+	if err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// withoutReloadable returns a copy of c without the settings which can be
+// changed at runtime.
+func (c *Config) withoutReloadable() Config {
+	r := *c
+	r.MaxRunning, r.MaxRunningPerCaller = 0, 0
+	r.OutputBuffer = OutputBufferConfig{}
+	r.Log.Level = ""
+	return r
+}
+
+// RequiresRestart returns whether the change from c to other involves
+// settings which can not be changed at runtime. The max running jobs, the
+// output buffer and the log level can be changed at runtime.
+func (c *Config) RequiresRestart(other *Config) bool {
+	// compared in YAML, so nil and empty lists are equal.
+	a, errA := yaml.Marshal(c.withoutReloadable())
+	b, errB := yaml.Marshal(other.withoutReloadable())
+	return errA != nil || errB != nil || !bytes.Equal(a, b)
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func load(t *testing.T, args ...string) *Config {
+	t.Helper()
+	c, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	filename := path.Join(t.TempDir(), name)
+	err := os.WriteFile(filename, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	filename := writeConfig(t, "rce.yaml", `
+max_running: 2
+max_running_per_caller: 1
+shutdown_timeout: 30s
+cache:
+  dir: /var/cache/rce
+log:
+  level: warn
+`)
+	t.Setenv("RCE_MAX_RUNNING", "3")
+	c := load(t, "--config", filename, "--log-level=debug")
+	if c.MaxRunning != 3 || c.MaxRunningPerCaller != 1 {
+		t.Fatalf("unexpected max running %d, per caller %d", c.MaxRunning, c.MaxRunningPerCaller)
+	}
+	if c.ShutdownTimeout != 30*time.Second || c.Cache.Dir != "/var/cache/rce" {
+		t.Fatalf("unexpected settings of file %+v", c)
+	}
+	if c.Log.Level != "debug" {
+		t.Fatalf("expect flag overrides file, got log level %s", c.Log.Level)
+	}
+	// the defaults are kept.
+	if len(c.Addresses) != 1 || c.Addresses[0] != ":8999" || c.OutputBuffer.Size != 1<<20 {
+		t.Fatalf("unexpected defaults %+v", c)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	filename := writeConfig(t, "rce.toml", `
+addresses = ["127.0.0.1:9000", "unix:///run/rce.sock"]
+compression = ["gzip"]
+health_interval = "5s"
+
+[output_buffer]
+size = 1024
+max = 4096
+
+[keepalive]
+time = "30s"
+`)
+	c := load(t, "--config", filename)
+	if len(c.Addresses) != 2 || c.Addresses[1] != "unix:///run/rce.sock" ||
+		len(c.Compression) != 1 || c.Compression[0] != "gzip" || c.HealthInterval != 5*time.Second || c.OutputBuffer.Size != 1024 || c.OutputBuffer.Max != 4096 {
+		t.Fatalf("unexpected config %+v", c)
+	}
+	if c.Keepalive.Time != 30*time.Second || c.Keepalive.MinTime != 10*time.Second {
+		t.Fatalf("unexpected keepalive %+v", c.Keepalive)
+	}
+}
+
+func TestLoadUnknownField(t *testing.T) {
+	for name, content := range map[string]string{
+		"rce.yaml": "max_runing: 2\n",
+		"rce.toml": "max_runing = 2\n\n[log]\nlevel = \"info\"\nlevle = \"info\"\n",
+	} {
+		filename := writeConfig(t, name, content)
+		_, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"--config", filename})
+		if err == nil {
+			t.Fatalf("expect error of unknown field in %s", name)
+		}
+		if name == "rce.toml" && (!strings.Contains(err.Error(), "max_runing") || !strings.Contains(err.Error(), "log.levle")) {
+			t.Fatalf("expect unknown fields in the error, got %v", err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := load(t, "--output-buffer-size=2048", "--output-buffer-max=1024", "--compression=lz4", "--log-format=xml")
+	if err := c.Validate(); err == nil {
+		t.Fatal("expect invalid config")
+	}
+}
+
+func TestWriteAndRequiresRestart(t *testing.T) {
+	c := load(t, "--max-running=4")
+	var buf bytes.Buffer
+	if err := c.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	other := load(t, "--config", writeConfig(t, "rce.yaml", buf.String()))
+	if c.RequiresRestart(other) {
+		t.Fatalf("config changed after write:\n%s", buf.String())
+	}
+	other.MaxRunning = 8
+	other.Log.Level = "debug"
+	if c.RequiresRestart(other) {
+		t.Fatal("reloadable settings require restart")
+	}
+	other.Addresses = []string{":9000"}
+	if !c.RequiresRestart(other) {
+		t.Fatal("address change does not require restart")
+	}
+}
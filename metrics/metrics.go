@@ -0,0 +1,67 @@
+// Package metrics defines the Prometheus metrics of the RCE server. The
+// metrics are registered to the default registry.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "rce"
+
+// Outcomes of Spawn streams.
+const (
+	OutcomeExited   = "exited"
+	OutcomeError    = "error"
+	OutcomeRejected = "rejected"
+	OutcomeCanceled = "canceled"
+)
+
+var (
+	SpawnsActive = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "spawns_active",
+		Help:      "Number of Spawn streams in progress, including the queued ones.",
+	})
+	SpawnsQueued = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "spawns_queued",
+		Help:      "Number of jobs waiting in the queue.",
+	})
+	SpawnsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "spawns_total",
+		Help:      "Number of finished Spawn streams by outcome.",
+	}, []string{"outcome"})
+	ExitCodes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "exit_codes_total",
+		Help:      "Number of exited processes by exit code.",
+	}, []string{"code"})
+	SpawnLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "spawn_latency_seconds",
+		Help:      "Time from receiving Head to sending PID, including uploads and queueing.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	})
+	UploadedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploaded_bytes_total",
+		Help:      "Bytes uploaded by clients by kind, file, archive or blob.",
+	}, []string{"kind"})
+	StreamedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "streamed_bytes_total",
+		Help:      "Bytes of output sent to clients by stream, stdout or stderr.",
+	}, []string{"stream"})
+	KillRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kill_requests_total",
+		Help:      "Number of Kill requests by result, ok, not_found or error.",
+	}, []string{"result"})
+	FileWriteErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "file_write_errors_total",
+		Help:      "Number of failed file, archive and cached file uploads.",
+	})
+)
@@ -0,0 +1,218 @@
+package process
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"github.com/reyoung/rce/protocol"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+)
+
+var (
+	errArchiveUnsafePath = errors.New("unsafe path in archive")
+)
+
+// archiveExtractor extracts a streamed tar archive into a directory.
+// Content is fed by Write, and extraction runs in a background goroutine.
+type archiveExtractor struct {
+	writer *io.PipeWriter
+	done   chan error
+}
+
+func newArchiveExtractor(dir string, compression protocol.SpawnRequest_Archive_Compression,
+	preserveOwner bool, logger *slog.Logger) *archiveExtractor {
+	pr, pw := io.Pipe()
+	a := &archiveExtractor{
+		writer: pw,
+		done:   make(chan error, 1),
+	}
+	go func() {
+		err := extractArchive(dir, compression, preserveOwner, pr, logger)
+		// unblock the writer if extraction stops early.
+		_ = pr.CloseWithError(err)
+		a.done <- err
+	}()
+	return a
+}
+
+func (a *archiveExtractor) Write(content []byte) error {
+	_, err := a.writer.Write(content)
+	if err != nil {
+		return errors.Join(err, <-a.done)
+	}
+	return nil
+}
+
+// Finish marks the end of the archive stream and waits for the extraction.
+func (a *archiveExtractor) Finish() error {
+	_ = a.writer.Close()
+	return <-a.done
+}
+
+// Abort stops the extraction and waits for the background goroutine.
+func (a *archiveExtractor) Abort() {
+	_ = a.writer.CloseWithError(errors.New("archive aborted"))
+	<-a.done
+}
+
+func newDecompressReader(
+	compression protocol.SpawnRequest_Archive_Compression, reader io.Reader) (io.ReadCloser, error) {
+	switch compression {
+	case protocol.SpawnRequest_Archive_NONE:
+		return io.NopCloser(reader), nil
+	case protocol.SpawnRequest_Archive_GZIP:
+		return gzip.NewReader(reader)
+	case protocol.SpawnRequest_Archive_ZSTD:
+		dec, err := zstd.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	default:
+		return nil, fmt.Errorf("unknown compression %v", compression)
+	}
+}
+
+// archiveEntryPath returns the path of an archive entry inside dir.
+// Entries must be relative and must not escape dir.
+func archiveEntryPath(dir, name string) (string, error) {
+	if strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("%w: %s", errArchiveUnsafePath, name)
+	}
+	cleaned := path.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %s", errArchiveUnsafePath, name)
+	}
+	return path.Join(dir, cleaned), nil
+}
+
+// archiveEntryMetadata returns the metadata of a tar entry.
+func archiveEntryMetadata(hdr *tar.Header, preserveOwner bool) *protocol.FileMetadata {
+	md := &protocol.FileMetadata{Mode: uint32(hdr.Mode) & 07777}
+	if !hdr.ModTime.IsZero() {
+		md.MtimeNs = hdr.ModTime.UnixNano()
+	}
+	if hdr.Typeflag == tar.TypeSymlink {
+		md.SymlinkTarget = hdr.Linkname
+	}
+	if preserveOwner {
+		md.Owner = &protocol.FileMetadata_Owner{Uid: uint32(hdr.Uid), Gid: uint32(hdr.Gid)}
+	}
+	return md
+}
+
+func extractArchive(dir string, compression protocol.SpawnRequest_Archive_Compression,
+	preserveOwner bool, reader io.Reader, logger *slog.Logger) error {
+	dec, err := newDecompressReader(compression, reader)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer dec.Close()
+
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create dir %s: %w", dir, err)
+	}
+
+	type dirMetadata struct {
+		dir string
+		md  *protocol.FileMetadata
+	}
+	// metadata of dirs is applied at last, since creating entries in a dir
+	// changes its mtime.
+	var dirs []dirMetadata
+
+	tr := tar.NewReader(dec)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("failed to read archive: %w", err)
+		}
+		filename, err := archiveEntryPath(dir, hdr.Name)
+		if err != nil {
+			return err
+		}
+		err = checkNoSymlink(dir, filename)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errArchiveUnsafePath, err)
+		}
+		md := archiveEntryMetadata(hdr, preserveOwner)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			// MkdirAll follows an existing symlink, and the metadata of the
+			// dir would be applied to its target.
+			if info, err := os.Lstat(filename); err == nil && info.Mode()&os.ModeSymlink != 0 {
+				return fmt.Errorf("%w: %w: %s", errArchiveUnsafePath, errUnsafeSymlink, filename)
+			}
+			err = os.MkdirAll(filename, 0700)
+			if err != nil {
+				return fmt.Errorf("failed to create dir %s: %w", filename, err)
+			}
+			dirs = append(dirs, dirMetadata{dir: filename, md: md})
+			continue
+		case tar.TypeReg:
+			logger.Debug("Extracting file", "filename", filename, "bytes", hdr.Size)
+			err = extractArchiveFile(filename, hdr, tr)
+		case tar.TypeSymlink:
+			err = createSymlink(filename, hdr.Linkname)
+		default:
+			return fmt.Errorf("unsupported archive entry %s, type %c", hdr.Name, hdr.Typeflag)
+		}
+		if err != nil {
+			return err
+		}
+		err = applyMetadata(filename, md)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		err = applyMetadata(dirs[i].dir, dirs[i].md)
+		if err != nil {
+			return err
+		}
+	}
+	// consume the padding after the end of archive.
+	_, err = io.Copy(io.Discard, dec)
+	return err
+}
+
+func extractArchiveFile(filename string, hdr *tar.Header, reader io.Reader) error {
+	err := os.MkdirAll(path.Dir(filename), 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create dir %s: %w", path.Dir(filename), err)
+	}
+	// opening an existing symlink writes to its target, remove it first.
+	if info, err := os.Lstat(filename); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		err = os.Remove(filename)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s: %w", filename, err)
+		}
+	}
+
+	var perm os.FileMode = 0600
+	if hdr.Mode&0100 != 0 {
+		perm = 0700
+	}
+	of, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+	defer of.Close()
+	_, err = io.Copy(of, reader)
+	if err != nil {
+		return fmt.Errorf("failed to write to file %s: %w", filename, err)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package process
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"github.com/reyoung/rce/protocol"
+	"log/slog"
+	"os"
+	"path"
+	"testing"
+	"time"
+)
+
+func makeTestArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tw.Write([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveExtractor(t *testing.T) {
+	dir := t.TempDir()
+	content := makeTestArchive(t, map[string]string{"a/b.sh": "echo hello", "c.txt": "world"})
+	a := newArchiveExtractor(dir, protocol.SpawnRequest_Archive_GZIP, false, slog.Default())
+	// feed in small chunks, like the client does.
+	for len(content) > 0 {
+		n := min(len(content), 7)
+		if err := a.Write(content[:n]); err != nil {
+			t.Fatal(err)
+		}
+		content = content[n:]
+	}
+	if err := a.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path.Join(dir, "a/b.sh"))
+	if err != nil || string(data) != "echo hello" {
+		t.Fatalf("unexpected content %q, %v", data, err)
+	}
+	st, err := os.Stat(path.Join(dir, "a/b.sh"))
+	if err != nil || st.Mode().Perm() != 0755 {
+		t.Fatalf("unexpected mode %v, %v", st.Mode(), err)
+	}
+}
+
+func TestArchiveExtractorUnsafePath(t *testing.T) {
+	dir := t.TempDir()
+	content := makeTestArchive(t, map[string]string{"../escape.txt": "oops"})
+	a := newArchiveExtractor(path.Join(dir, "sub"), protocol.SpawnRequest_Archive_GZIP, false, slog.Default())
+	err := a.Write(content)
+	if err == nil {
+		err = a.Finish()
+	}
+	if !errors.Is(err, errArchiveUnsafePath) {
+		t.Fatalf("expect unsafe path error, got %v", err)
+	}
+	if _, err := os.Stat(path.Join(dir, "escape.txt")); !os.IsNotExist(err) {
+		t.Fatalf("file escaped the archive dir")
+	}
+}
+
+func TestArchiveExtractorMetadata(t *testing.T) {
+	dir := t.TempDir()
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range []*tar.Header{
+		{Name: "sub/", Typeflag: tar.TypeDir, Mode: 0750, ModTime: mtime},
+		{Name: "sub/a.txt", Typeflag: tar.TypeReg, Mode: 0644, ModTime: mtime},
+		{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "sub/a.txt", ModTime: mtime},
+	} {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	a := newArchiveExtractor(dir, protocol.SpawnRequest_Archive_NONE, false, slog.Default())
+	if err := a.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Finish(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, mode := range map[string]os.FileMode{"sub": os.ModeDir | 0750, "sub/a.txt": 0644} {
+		st, err := os.Stat(path.Join(dir, name))
+		if err != nil || st.Mode() != mode || !st.ModTime().Equal(mtime) {
+			t.Fatalf("unexpected metadata of %s, %v, %v, %v", name, st.Mode(), st.ModTime(), err)
+		}
+	}
+	target, err := os.Readlink(path.Join(dir, "link"))
+	if err != nil || target != "sub/a.txt" {
+		t.Fatalf("unexpected symlink %s, %v", target, err)
+	}
+}
+
+func TestArchiveExtractorThroughSymlink(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range []*tar.Header{
+		{Name: "escape", Typeflag: tar.TypeSymlink, Linkname: outside},
+		{Name: "escape/a.txt", Typeflag: tar.TypeReg, Mode: 0644},
+	} {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	a := newArchiveExtractor(dir, protocol.SpawnRequest_Archive_NONE, false, slog.Default())
+	err := a.Write(buf.Bytes())
+	if err == nil {
+		err = a.Finish()
+	}
+	if !errors.Is(err, errArchiveUnsafePath) {
+		t.Fatalf("expect unsafe path error, got %v", err)
+	}
+	if _, err := os.Stat(path.Join(outside, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("file escaped through symlink")
+	}
+}
+
+func TestArchiveExtractorDirSymlink(t *testing.T) {
+	for name, hdrs := range map[string][]*tar.Header{
+		"symlink before dir": {
+			{Name: "d", Typeflag: tar.TypeSymlink},
+			{Name: "d/", Typeflag: tar.TypeDir, Mode: 0777},
+		},
+		"symlink after dir": {
+			{Name: "d/", Typeflag: tar.TypeDir, Mode: 0777},
+			{Name: "d", Typeflag: tar.TypeSymlink},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			outside := t.TempDir()
+			if err := os.Chmod(outside, 0700); err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			tw := tar.NewWriter(&buf)
+			for _, hdr := range hdrs {
+				if hdr.Typeflag == tar.TypeSymlink {
+					hdr.Linkname = outside
+				}
+				if err := tw.WriteHeader(hdr); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			a := newArchiveExtractor(dir, protocol.SpawnRequest_Archive_NONE, false, slog.Default())
+			err := a.Write(buf.Bytes())
+			if err == nil {
+				err = a.Finish()
+			}
+			if err == nil {
+				t.Fatal("expect error")
+			}
+			st, err := os.Stat(outside)
+			if err != nil || st.Mode().Perm() != 0700 {
+				t.Fatalf("mode of symlink target changed to %v, %v", st.Mode().Perm(), err)
+			}
+		})
+	}
+}
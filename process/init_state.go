@@ -8,6 +8,7 @@ import (
 
 // initState is the initial state of the process.
 type initState struct {
+	opts *options
 }
 
 func (s *initState) Output() <-chan *stateOutput {
@@ -21,12 +22,12 @@ func (s *initState) Close() error {
 func (s *initState) ProcessEvent(ctx context.Context, event *protocol.SpawnRequest) (newState state, err error) {
 	switch v := event.Payload.(type) {
 	case *protocol.SpawnRequest_Head_:
-		return s.processHead(v.Head)
+		return s.processHead(ctx, v.Head)
 	default:
 		return nil, fmt.Errorf("%w: %T", errStateUnexpectedEvent, event.Payload)
 	}
 }
 
-func (s *initState) processHead(head *protocol.SpawnRequest_Head) (state, error) {
-	return newPreparingState(head)
+func (s *initState) processHead(ctx context.Context, head *protocol.SpawnRequest_Head) (state, error) {
+	return newPreparingState(ctx, head, s.opts)
 }
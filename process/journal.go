@@ -0,0 +1,179 @@
+package process
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+)
+
+// Journal records the workspaces and the process groups of the jobs on disk,
+// so the ones left by a crashed server can be cleaned when it restarts.
+type Journal struct {
+	dir string
+}
+
+type journalEntry struct {
+	// Workspace is the temp dir created for the job.
+	Workspace string `json:"workspace,omitempty"`
+	// Pgid is the process group and the session of the job.
+	Pgid int `json:"pgid,omitempty"`
+	// BootID and StartTime identify the leader process, since the pid may be
+	// reused after it exits.
+	BootID    string `json:"boot_id,omitempty"`
+	StartTime uint64 `json:"start_time,omitempty"`
+	// JobID finds the processes which left the session of the job.
+	JobID string `json:"job_id,omitempty"`
+}
+
+// OpenJournal opens the journal in dir, dir is created if not exists.
+func OpenJournal(dir string) (*Journal, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journal dir: %w", err)
+	}
+	return &Journal{dir: dir}, nil
+}
+
+// spillDir is the dir of the spill files of output buffers, which are
+// removed by Recover. Without a journal, they are in the temp dir.
+func (j *Journal) spillDir() string {
+	if j == nil {
+		return ""
+	}
+	return j.dir
+}
+
+func (j *Journal) filename(id string) string {
+	return path.Join(j.dir, id+".json")
+}
+
+func (j *Journal) write(id string, entry *journalEntry) error {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	tmp := j.filename(id) + ".tmp"
+	err = os.WriteFile(tmp, buf, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	err = os.Rename(tmp, j.filename(id))
+	if err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+func (j *Journal) remove(id string) error {
+	err := os.Remove(j.filename(id))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove journal: %w", err)
+	}
+	return nil
+}
+
+// recoverEntry kills the processes and removes the workspace of entry.
+func recoverEntry(entry *journalEntry) error {
+	if entry.Pgid != 0 && entry.BootID != "" && entry.BootID == bootID() {
+		startTime, err := processStartTime(entry.Pgid)
+		if err == nil && startTime != entry.StartTime {
+			slog.Info("Leftover process is reused, not killed", "os_pid", entry.Pgid)
+		} else {
+			// the leader may have exited, the rest of its job is killed.
+			if err == nil {
+				slog.Info("Killing leftover process group", "pgid", entry.Pgid)
+				_ = killProcessGroup(entry.Pgid)
+			}
+			err = reapJob(entry.Pgid, entry.JobID, slog.Default())
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if entry.Workspace != "" {
+		slog.Info("Removing leftover workspace", "dir", entry.Workspace)
+		err := os.RemoveAll(entry.Workspace)
+		if err != nil {
+			return fmt.Errorf("failed to remove workspace: %w", err)
+		}
+	}
+	return nil
+}
+
+// Recover kills the processes and removes the workspaces recorded in the
+// journal. It must be called before any job starts.
+func (j *Journal) Recover() error {
+	entries, err := os.ReadDir(j.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read journal dir: %w", err)
+	}
+	var errs []error
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasSuffix(name, ".tmp") || strings.HasSuffix(name, spillSuffix) {
+			_ = os.Remove(path.Join(j.dir, name))
+			continue
+		}
+		id, ok := strings.CutSuffix(name, ".json")
+		if !ok {
+			continue
+		}
+		buf, err := os.ReadFile(j.filename(id))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read journal %s: %w", id, err))
+			continue
+		}
+		entry := &journalEntry{}
+		err = json.Unmarshal(buf, entry)
+		if err == nil {
+			err = recoverEntry(entry)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to recover journal %s: %w", id, err))
+			continue
+		}
+		errs = append(errs, j.remove(id))
+	}
+	return errors.Join(errs...)
+}
+
+// journalRecord is the journal entry of a job. A nil journalRecord records
+// nothing.
+type journalRecord struct {
+	journal *Journal
+	id      string
+	entry   journalEntry
+}
+
+func (j *Journal) newRecord() *journalRecord {
+	if j == nil {
+		return nil
+	}
+	return &journalRecord{journal: j, id: uuid.New().String()}
+}
+
+func (r *journalRecord) Update(update func(entry *journalEntry)) {
+	if r == nil {
+		return
+	}
+	update(&r.entry)
+	err := r.journal.write(r.id, &r.entry)
+	if err != nil {
+		slog.Warn("Failed to record journal", "id", r.id, "err", err)
+	}
+}
+
+func (r *journalRecord) Remove() {
+	if r == nil {
+		return
+	}
+	err := r.journal.remove(r.id)
+	if err != nil {
+		slog.Warn("Failed to remove journal", "id", r.id, "err", err)
+	}
+}
@@ -0,0 +1,112 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"github.com/reyoung/rce/protocol"
+	"golang.org/x/sys/unix"
+	"os"
+	"path"
+	"strings"
+)
+
+var (
+	errUnsafeSymlink = errors.New("path contains symlink")
+)
+
+// fileModeFromUnix converts unix permission bits to os.FileMode.
+func fileModeFromUnix(mode uint32) os.FileMode {
+	m := os.FileMode(mode & 0777)
+	if mode&unix.S_ISUID != 0 {
+		m |= os.ModeSetuid
+	}
+	if mode&unix.S_ISGID != 0 {
+		m |= os.ModeSetgid
+	}
+	if mode&unix.S_ISVTX != 0 {
+		m |= os.ModeSticky
+	}
+	return m
+}
+
+// applyMetadata sets the owner, mode and mtime of filename. filename is not
+// followed if it is a symlink.
+func applyMetadata(filename string, md *protocol.FileMetadata) error {
+	if md == nil {
+		return nil
+	}
+	isSymlink := md.SymlinkTarget != ""
+	if owner := md.GetOwner(); owner != nil {
+		// chown clears setuid and setgid bits, it must be done before chmod.
+		err := os.Lchown(filename, int(owner.Uid), int(owner.Gid))
+		if err != nil {
+			return fmt.Errorf("failed to chown %s: %w", filename, err)
+		}
+	}
+	if md.Mode != 0 && !isSymlink {
+		err := chmodNoFollow(filename, fileModeFromUnix(md.Mode))
+		if err != nil {
+			return fmt.Errorf("failed to chmod %s: %w", filename, err)
+		}
+	}
+	if md.MtimeNs != 0 {
+		ts := unix.NsecToTimespec(md.MtimeNs)
+		err := unix.UtimesNanoAt(unix.AT_FDCWD, filename, []unix.Timespec{ts, ts}, unix.AT_SYMLINK_NOFOLLOW)
+		if err != nil {
+			return fmt.Errorf("failed to set mtime of %s: %w", filename, err)
+		}
+	}
+	return nil
+}
+
+// chmodNoFollow changes the mode of filename, and fails if filename is a
+// symlink. A symlink may replace a file after it is created, e.g. a dir in an
+// archive, whose metadata is applied at last.
+func chmodNoFollow(filename string, mode os.FileMode) error {
+	f, err := os.OpenFile(filename, os.O_RDONLY|unix.O_NOFOLLOW|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Chmod(mode)
+}
+
+// createSymlink creates filename as a symlink to target, replacing the
+// existing file.
+func createSymlink(filename, target string) error {
+	err := os.MkdirAll(path.Dir(filename), 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create dir %s: %w", path.Dir(filename), err)
+	}
+	err = os.Remove(filename)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", filename, err)
+	}
+	err = os.Symlink(target, filename)
+	if err != nil {
+		return fmt.Errorf("failed to create symlink %s: %w", filename, err)
+	}
+	return nil
+}
+
+// checkNoSymlink checks that no parent of filename inside dir is a symlink,
+// so that writing filename never escapes dir through a symlink.
+func checkNoSymlink(dir, filename string) error {
+	rel := strings.TrimPrefix(strings.TrimPrefix(filename, dir), "/")
+	parts := strings.Split(rel, "/")
+	cur := dir
+	for _, part := range parts[:len(parts)-1] {
+		cur = path.Join(cur, part)
+		info, err := os.Lstat(cur)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return fmt.Errorf("failed to stat %s: %w", cur, err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return fmt.Errorf("%w: %s", errUnsafeSymlink, cur)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package process
+
+import (
+	"github.com/reyoung/rce/cas"
+	"log/slog"
+)
+
+type options struct {
+	blobs            *cas.Store
+	outputBufferSize int
+	outputBufferMax  int
+	journal          *Journal
+	logger           *slog.Logger
+}
+
+type Option func(*options)
+
+func newOptions(opts ...Option) *options {
+	o := &options{
+		outputBufferSize: DefaultOutputBufferSize,
+		outputBufferMax:  DefaultOutputBufferMax,
+		logger:           slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithBlobStore enables CachedFile events, materialized from store.
+func WithBlobStore(store *cas.Store) Option {
+	return func(o *options) {
+		o.blobs = store
+	}
+}
+
+// WithOutputBuffer sets the default and the max size of the output buffer
+// of a process.
+func WithOutputBuffer(size, maxSize int) Option {
+	return func(o *options) {
+		o.outputBufferSize = size
+		o.outputBufferMax = maxSize
+	}
+}
+
+// WithJournal records the workspaces and the processes of jobs in j.
+func WithJournal(j *Journal) Option {
+	return func(o *options) {
+		o.journal = j
+	}
+}
+
+// WithLogger sets the logger of a process, which usually carries the fields
+// of the job.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
@@ -0,0 +1,263 @@
+package process
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/reyoung/rce/protocol"
+	"os"
+	"sync"
+)
+
+// DefaultOutputBufferSize and DefaultOutputBufferMax are the output buffer
+// sizes used without WithOutputBuffer.
+const (
+	DefaultOutputBufferSize = 1 << 20
+	DefaultOutputBufferMax  = 64 << 20
+)
+
+type outputChunk struct {
+	stderr    bool
+	sequence  uint64
+	timestamp int64 // unix nanoseconds
+	data      []byte
+}
+
+func (c *outputChunk) response() *protocol.SpawnResponse {
+	if c.stderr {
+		return &protocol.SpawnResponse{Payload: &protocol.SpawnResponse_Stderr_{
+			Stderr: &protocol.SpawnResponse_Stderr{Stderr: c.data, Sequence: c.sequence, TimestampNs: c.timestamp}}}
+	}
+	return &protocol.SpawnResponse{Payload: &protocol.SpawnResponse_Stdout_{
+		Stdout: &protocol.SpawnResponse_Stdout{Stdout: c.data, Sequence: c.sequence, TimestampNs: c.timestamp}}}
+}
+
+// spillFile is a FIFO of output chunks on disk.
+type spillFile struct {
+	file     *os.File
+	readOff  int64
+	writeOff int64
+}
+
+// spillSuffix is the suffix of spill files, which are removed by
+// Journal.Recover if the server crashes.
+const spillSuffix = ".spill"
+
+// spillHeaderSize is the size of kind, sequence, timestamp and data length.
+const spillHeaderSize = 21
+
+func (f *spillFile) Empty() bool {
+	return f.readOff == f.writeOff
+}
+
+// Size returns the size of the file, which is truncated once it is empty.
+func (f *spillFile) Size() int {
+	return int(f.writeOff)
+}
+
+func (f *spillFile) Write(chunk outputChunk) error {
+	var hdr [spillHeaderSize]byte
+	if chunk.stderr {
+		hdr[0] = 1
+	}
+	binary.LittleEndian.PutUint64(hdr[1:], chunk.sequence)
+	binary.LittleEndian.PutUint64(hdr[9:], uint64(chunk.timestamp))
+	binary.LittleEndian.PutUint32(hdr[17:], uint32(len(chunk.data)))
+	_, err := f.file.WriteAt(append(hdr[:], chunk.data...), f.writeOff)
+	if err != nil {
+		return fmt.Errorf("failed to write spill file: %w", err)
+	}
+	f.writeOff += int64(spillHeaderSize + len(chunk.data))
+	return nil
+}
+
+func (f *spillFile) Read() (chunk outputChunk, err error) {
+	var hdr [spillHeaderSize]byte
+	_, err = f.file.ReadAt(hdr[:], f.readOff)
+	if err != nil {
+		return chunk, fmt.Errorf("failed to read spill file: %w", err)
+	}
+	chunk.stderr = hdr[0] == 1
+	chunk.sequence = binary.LittleEndian.Uint64(hdr[1:])
+	chunk.timestamp = int64(binary.LittleEndian.Uint64(hdr[9:]))
+	chunk.data = make([]byte, binary.LittleEndian.Uint32(hdr[17:]))
+	_, err = f.file.ReadAt(chunk.data, f.readOff+spillHeaderSize)
+	if err != nil {
+		return chunk, fmt.Errorf("failed to read spill file: %w", err)
+	}
+	f.readOff += int64(spillHeaderSize + len(chunk.data))
+	if f.Empty() {
+		// reuse the file from the beginning.
+		f.readOff, f.writeOff = 0, 0
+		return chunk, f.file.Truncate(0)
+	}
+	return chunk, nil
+}
+
+func (f *spillFile) Close() error {
+	_ = f.file.Close()
+	return os.Remove(f.file.Name())
+}
+
+// outputBuffer is a bounded FIFO between the output readers and the state
+// output channel. When it is full, the overflow policy decides whether the
+// readers block, the oldest output is dropped, or the output spills to disk.
+// The spill file is created in spillDir and bounded by spillLimit, the
+// readers block when it is full.
+type outputBuffer struct {
+	limit      int
+	policy     protocol.SpawnRequest_Head_OutputBuffer_OverflowPolicy
+	spillDir   string
+	spillLimit int
+
+	mutex         sync.Mutex
+	cond          sync.Cond
+	chunks        []outputChunk
+	size          int
+	droppedStdout uint64
+	droppedStderr uint64
+	sequence      uint64
+	spill         *spillFile
+	closed        bool
+}
+
+func newOutputBuffer(limit int, policy protocol.SpawnRequest_Head_OutputBuffer_OverflowPolicy,
+	spillDir string, spillLimit int) *outputBuffer {
+	b := &outputBuffer{limit: limit, policy: policy, spillDir: spillDir, spillLimit: spillLimit}
+	b.cond.L = &b.mutex
+	return b
+}
+
+func (b *outputBuffer) drop(stderr bool, n int) {
+	if stderr {
+		b.droppedStderr += uint64(n)
+	} else {
+		b.droppedStdout += uint64(n)
+	}
+}
+
+// dropOldest drops output until chunk fits in the buffer. mutex must be held.
+func (b *outputBuffer) dropOldest(chunk *outputChunk) {
+	if len(chunk.data) > b.limit {
+		b.drop(chunk.stderr, len(chunk.data)-b.limit)
+		chunk.data = chunk.data[len(chunk.data)-b.limit:]
+	}
+	for b.size+len(chunk.data) > b.limit && len(b.chunks) > 0 {
+		old := b.chunks[0]
+		b.chunks = b.chunks[1:]
+		b.size -= len(old.data)
+		b.drop(old.stderr, len(old.data))
+	}
+}
+
+// spillChunk writes chunk to the spill file. mutex must be held.
+func (b *outputBuffer) spillChunk(chunk outputChunk) error {
+	if b.spill == nil {
+		f, err := os.CreateTemp(b.spillDir, "rce-output-*"+spillSuffix)
+		if err != nil {
+			return fmt.Errorf("failed to create spill file: %w", err)
+		}
+		b.spill = &spillFile{file: f}
+	}
+	err := b.spill.Write(chunk)
+	if err != nil {
+		return err
+	}
+	b.cond.Broadcast()
+	return nil
+}
+
+// Push appends chunk to the buffer and numbers it, so the sequence follows
+// the order of the output. With the block policy, it blocks until the buffer
+// has room or the buffer is closed.
+func (b *outputBuffer) Push(chunk outputChunk) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	full := func() bool {
+		return b.size > 0 && b.size+len(chunk.data) > b.limit
+	}
+	switch b.policy {
+	case protocol.SpawnRequest_Head_OutputBuffer_DROP_OLDEST:
+		b.dropOldest(&chunk)
+	case protocol.SpawnRequest_Head_OutputBuffer_SPILL:
+		// keep the order, once spilled, output goes to disk until it is read.
+		for {
+			spilled := b.spill != nil && !b.spill.Empty()
+			if !full() && !spilled {
+				break
+			}
+			if !spilled || b.closed || b.spill.Size()+spillHeaderSize+len(chunk.data) <= b.spillLimit {
+				b.sequence++
+				chunk.sequence = b.sequence
+				return b.spillChunk(chunk)
+			}
+			b.cond.Wait()
+		}
+	default:
+		for !b.closed && full() {
+			b.cond.Wait()
+		}
+	}
+	b.sequence++
+	chunk.sequence = b.sequence
+	b.chunks = append(b.chunks, chunk)
+	b.size += len(chunk.data)
+	b.cond.Broadcast()
+	return nil
+}
+
+// Pop returns the next output, a Gap response is returned before the output
+// following dropped output. It blocks until there is output, and returns
+// false if the buffer is closed and drained.
+func (b *outputBuffer) Pop() (*stateOutput, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for {
+		if b.droppedStdout != 0 || b.droppedStderr != 0 {
+			gap := &protocol.SpawnResponse_Gap{StdoutBytes: b.droppedStdout, StderrBytes: b.droppedStderr}
+			b.droppedStdout, b.droppedStderr = 0, 0
+			return &stateOutput{Response: &protocol.SpawnResponse{
+				Payload: &protocol.SpawnResponse_Gap_{Gap: gap}}}, true
+		}
+		if len(b.chunks) != 0 {
+			chunk := b.chunks[0]
+			b.chunks = b.chunks[1:]
+			b.size -= len(chunk.data)
+			b.cond.Broadcast()
+			return &stateOutput{Response: chunk.response()}, true
+		}
+		if b.spill != nil && !b.spill.Empty() {
+			chunk, err := b.spill.Read()
+			if err != nil {
+				_ = b.spill.Close()
+				b.spill = nil
+				return &stateOutput{Error: err}, true
+			}
+			b.cond.Broadcast()
+			return &stateOutput{Response: chunk.response()}, true
+		}
+		if b.closed {
+			return nil, false
+		}
+		b.cond.Wait()
+	}
+}
+
+// Close marks that no more output will be pushed, and unblocks Push.
+func (b *outputBuffer) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.closed = true
+	b.cond.Broadcast()
+}
+
+// Release removes the spill file.
+func (b *outputBuffer) Release() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.spill == nil {
+		return nil
+	}
+	err := b.spill.Close()
+	b.spill = nil
+	return err
+}
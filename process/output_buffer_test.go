@@ -0,0 +1,161 @@
+package process
+
+import (
+	"github.com/reyoung/rce/protocol"
+	"os"
+	"testing"
+	"time"
+)
+
+func popAll(t *testing.T, b *outputBuffer) (stdout string, gaps []*protocol.SpawnResponse_Gap) {
+	b.Close()
+	for {
+		out, ok := b.Pop()
+		if !ok {
+			return stdout, gaps
+		}
+		if out.Error != nil {
+			t.Fatal(out.Error)
+		}
+		stdout += string(out.Response.GetStdout().GetStdout())
+		if gap := out.Response.GetGap(); gap != nil {
+			gaps = append(gaps, gap)
+		}
+	}
+}
+
+func TestOutputBufferDropOldest(t *testing.T) {
+	b := newOutputBuffer(4, protocol.SpawnRequest_Head_OutputBuffer_DROP_OLDEST, "", 0)
+	defer b.Release()
+	for _, s := range []string{"ab", "cd", "ef", "ghijk"} {
+		if err := b.Push(outputChunk{data: []byte(s)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stdout, gaps := popAll(t, b)
+	if stdout != "hijk" {
+		t.Fatalf("unexpected output %q", stdout)
+	}
+	if len(gaps) != 1 || gaps[0].StdoutBytes != 7 || gaps[0].StderrBytes != 0 {
+		t.Fatalf("unexpected gaps %v", gaps)
+	}
+}
+
+func TestOutputBufferSpill(t *testing.T) {
+	b := newOutputBuffer(4, protocol.SpawnRequest_Head_OutputBuffer_SPILL, t.TempDir(), 1024)
+	defer b.Release()
+	for _, s := range []string{"ab", "cd", "ef", "gh"} {
+		if err := b.Push(outputChunk{data: []byte(s)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b.spill == nil || b.spill.Empty() {
+		t.Fatal("expect output spilled to disk")
+	}
+	out, _ := b.Pop()
+	if string(out.Response.GetStdout().GetStdout()) != "ab" {
+		t.Fatalf("unexpected output %v", out.Response)
+	}
+	// new output follows the spilled output even if memory has room.
+	if err := b.Push(outputChunk{data: []byte("ij")}); err != nil {
+		t.Fatal(err)
+	}
+	stdout, gaps := popAll(t, b)
+	if stdout != "cdefghij" || len(gaps) != 0 {
+		t.Fatalf("unexpected output %q, gaps %v", stdout, gaps)
+	}
+}
+
+func TestOutputBufferSpillLimit(t *testing.T) {
+	dir := t.TempDir()
+	// the spill file holds two chunks of 2 bytes.
+	b := newOutputBuffer(2, protocol.SpawnRequest_Head_OutputBuffer_SPILL, dir, 2*(spillHeaderSize+2))
+	defer b.Release()
+	for _, s := range []string{"ab", "cd", "ef"} {
+		if err := b.Push(outputChunk{data: []byte(s)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("expect the spill file in %s, got %d entries", dir, len(entries))
+	}
+	pushed := make(chan error)
+	go func() {
+		pushed <- b.Push(outputChunk{data: []byte("gh")})
+	}()
+	// the spill file is truncated after all spilled output is read.
+	for _, expected := range []string{"ab", "cd", "ef"} {
+		select {
+		case <-pushed:
+			t.Fatal("push should block when the spill file is full")
+		case <-time.After(20 * time.Millisecond):
+		}
+		out, _ := b.Pop()
+		if string(out.Response.GetStdout().GetStdout()) != expected {
+			t.Fatalf("unexpected output %v", out.Response)
+		}
+	}
+	if err := <-pushed; err != nil {
+		t.Fatal(err)
+	}
+	stdout, _ := popAll(t, b)
+	if stdout != "gh" {
+		t.Fatalf("unexpected output %q", stdout)
+	}
+}
+
+func TestOutputBufferBlock(t *testing.T) {
+	b := newOutputBuffer(4, protocol.SpawnRequest_Head_OutputBuffer_BLOCK, "", 0)
+	defer b.Release()
+	if err := b.Push(outputChunk{data: []byte("abcd")}); err != nil {
+		t.Fatal(err)
+	}
+	pushed := make(chan error)
+	go func() {
+		pushed <- b.Push(outputChunk{data: []byte("ef")})
+	}()
+	select {
+	case <-pushed:
+		t.Fatal("push should block when the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+	out, _ := b.Pop()
+	if string(out.Response.GetStdout().GetStdout()) != "abcd" {
+		t.Fatalf("unexpected output %v", out.Response)
+	}
+	if err := <-pushed; err != nil {
+		t.Fatal(err)
+	}
+	stdout, _ := popAll(t, b)
+	if stdout != "ef" {
+		t.Fatalf("unexpected output %q", stdout)
+	}
+}
+
+func TestOutputBufferSequence(t *testing.T) {
+	b := newOutputBuffer(2, protocol.SpawnRequest_Head_OutputBuffer_SPILL, t.TempDir(), 1024)
+	defer b.Release()
+	for i, s := range []string{"ab", "cd", "ef"} {
+		err := b.Push(outputChunk{stderr: i == 1, timestamp: int64(100 + i), data: []byte(s)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	b.Close()
+	for i := 0; ; i++ {
+		out, ok := b.Pop()
+		if !ok {
+			break
+		}
+		var sequence uint64
+		var timestamp int64
+		if stderr := out.Response.GetStderr(); stderr != nil {
+			sequence, timestamp = stderr.Sequence, stderr.TimestampNs
+		} else {
+			sequence, timestamp = out.Response.GetStdout().Sequence, out.Response.GetStdout().TimestampNs
+		}
+		if sequence != uint64(i+1) || timestamp != int64(100+i) {
+			t.Fatalf("unexpected sequence %d, timestamp %d of output %d", sequence, timestamp, i)
+		}
+	}
+}
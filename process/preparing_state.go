@@ -2,17 +2,39 @@ package process
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"github.com/reyoung/rce/metrics"
 	"github.com/reyoung/rce/protocol"
-	"log"
+	"github.com/reyoung/rce/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
 	"os"
 	"path"
 	"strings"
 )
 
+var (
+	errFileSizeMismatch   = errors.New("file size mismatch")
+	errFileDigestMismatch = errors.New("file sha256 mismatch")
+	errFileNotCommitted   = errors.New("file not committed")
+)
+
 type preparingState struct {
+	opts      *options
 	head      *protocol.SpawnRequest_Head
 	cleanPath bool
+	archive   *archiveExtractor
+	record    *journalRecord
+	logger    *slog.Logger
+	// span lasts until the process starts.
+	span trace.Span
+	// uncommitted files, which received content but not eof.
+	uncommitted map[string]struct{}
 }
 
 func (p *preparingState) ProcessEvent(ctx context.Context, event *protocol.SpawnRequest) (newState state, err error) {
@@ -20,20 +42,54 @@ func (p *preparingState) ProcessEvent(ctx context.Context, event *protocol.Spawn
 	case *protocol.SpawnRequest_Start_:
 		return p.processStartEvent(ctx, v.Start)
 	case *protocol.SpawnRequest_File_:
+		metrics.UploadedBytes.WithLabelValues("file").Add(float64(len(v.File.Content)))
 		err = p.processFileEvent(v.File)
 		if err != nil {
+			metrics.FileWriteErrors.Inc()
 			return nil, fmt.Errorf("failed to process file event: %w", err)
 		}
 		return nil, nil
+	case *protocol.SpawnRequest_CachedFile_:
+		err = p.processCachedFileEvent(v.CachedFile)
+		if err != nil {
+			metrics.FileWriteErrors.Inc()
+			return nil, fmt.Errorf("failed to process cached file event: %w", err)
+		}
+		return nil, nil
+	case *protocol.SpawnRequest_Archive_:
+		metrics.UploadedBytes.WithLabelValues("archive").Add(float64(len(v.Archive.Content)))
+		err = p.processArchiveEvent(v.Archive)
+		if err != nil {
+			metrics.FileWriteErrors.Inc()
+			return nil, fmt.Errorf("failed to process archive event: %w", err)
+		}
+		return nil, nil
 	default:
 		return nil, fmt.Errorf("%w: %T", errStateUnexpectedEvent, event.Payload)
 	}
 }
 
+// resolvePath returns the absolute path of filename. Relative paths are
+// relative to the working directory of the process.
+func (p *preparingState) resolvePath(filename string) string {
+	if !strings.HasPrefix(filename, "/") {
+		return path.Join(p.head.Path, filename)
+	}
+	return filename
+}
+
 func (p *preparingState) processFileEvent(file *protocol.SpawnRequest_File) (err error) {
-	if !strings.HasPrefix(file.Filename, "/") {
-		file.Filename = path.Join(p.head.Path, file.Filename)
+	file.Filename = p.resolvePath(file.Filename)
+	if target := file.GetMetadata().GetSymlinkTarget(); target != "" {
+		p.logger.Debug("Creating symlink", "filename", file.Filename, "target", target)
+		delete(p.uncommitted, file.Filename)
+		err = createSymlink(file.Filename, target)
+		if err != nil {
+			return err
+		}
+		return applyMetadata(file.Filename, file.Metadata)
 	}
+
 	flag := os.O_CREATE | os.O_WRONLY
 	if file.Truncate {
 		flag |= os.O_TRUNC
@@ -49,7 +105,14 @@ func (p *preparingState) processFileEvent(file *protocol.SpawnRequest_File) (err
 	if err != nil {
 		return fmt.Errorf("failed to create dir %s: %w", path.Dir(file.Filename), err)
 	}
-	log.Printf("Creating file %s", file.Filename)
+	p.logger.Debug("Writing file", "filename", file.Filename, "bytes", len(file.Content), "eof", file.Eof)
+	if info, err := os.Lstat(file.Filename); err == nil && file.Truncate && info.Mode()&os.ModeSymlink != 0 {
+		// replace the symlink, instead of writing to its target.
+		err = os.Remove(file.Filename)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s: %w", file.Filename, err)
+		}
+	}
 
 	of, err := os.OpenFile(file.Filename, flag, perm)
 	if err != nil {
@@ -60,14 +123,111 @@ func (p *preparingState) processFileEvent(file *protocol.SpawnRequest_File) (err
 	if err != nil {
 		return fmt.Errorf("failed to write to file %s: %w", file.Filename, err)
 	}
+	if !file.Eof {
+		p.uncommitted[file.Filename] = struct{}{}
+		return nil
+	}
+	delete(p.uncommitted, file.Filename)
+	err = verifyFile(of.Name(), file.Size, file.Sha256)
+	if err != nil {
+		return err
+	}
+	return applyMetadata(file.Filename, file.Metadata)
+}
+
+// verifyFile checks the size and sha256 of the file on disk, which catches
+// duplicated content of retried uploads in append mode.
+func verifyFile(filename string, size uint64, digest string) error {
+	if size == 0 && digest == "" {
+		return nil
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", filename, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %w", filename, err)
+	}
+	if size != 0 && uint64(n) != size {
+		return fmt.Errorf("%w: %s, expect %d bytes, actual %d bytes", errFileSizeMismatch, filename, size, n)
+	}
+	if actual := hex.EncodeToString(h.Sum(nil)); digest != "" && !strings.EqualFold(actual, digest) {
+		return fmt.Errorf("%w: %s, expect %s, actual %s", errFileDigestMismatch, filename, digest, actual)
+	}
+	return nil
+}
+
+func (p *preparingState) processCachedFileEvent(file *protocol.SpawnRequest_CachedFile) error {
+	if p.opts.blobs == nil {
+		return errors.New("file cache is not enabled")
+	}
+	filename := p.resolvePath(file.Filename)
+	var perm os.FileMode = 0600
+	if file.Executable {
+		perm = 0700
+	}
+	err := os.MkdirAll(path.Dir(filename), 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create dir %s: %w", path.Dir(filename), err)
+	}
+	p.logger.Debug("Creating file from blob", "filename", filename, "sha256", file.Sha256)
+	linked, err := p.opts.blobs.Materialize(file.Sha256, filename, perm, linkable(file.Metadata))
+	if err != nil {
+		return err
+	}
+	if linked {
+		// metadata must not be applied to the blob through a hard link.
+		return nil
+	}
+	return applyMetadata(filename, file.Metadata)
+}
+
+// linkable returns true if a file with md may be a hard link of a blob,
+// which is read-only and shared by jobs. The mtime in md is not kept then.
+func linkable(md *protocol.FileMetadata) bool {
+	return md == nil || (md.Owner == nil && md.Mode&^0444 == 0)
+}
+
+func (p *preparingState) processArchiveEvent(archive *protocol.SpawnRequest_Archive) (err error) {
+	if p.archive == nil {
+		dir := p.resolvePath(archive.Path)
+		p.logger.Info("Extracting archive", "dir", dir)
+		p.archive = newArchiveExtractor(dir, archive.Compression, archive.PreserveOwner, p.logger)
+	}
+	if len(archive.Content) != 0 {
+		err = p.archive.Write(archive.Content)
+		if err != nil {
+			p.archive = nil
+			return err
+		}
+	}
+	if archive.Eof {
+		err = p.archive.Finish()
+		p.archive = nil
+		return err
+	}
 	return nil
 }
 
 func (p *preparingState) processStartEvent(
 	ctx context.Context, start *protocol.SpawnRequest_Start) (newState state, err error) {
-	newState, err = newRunningState(ctx, p.head, p.cleanPath)
+	if p.archive != nil {
+		return nil, errors.New("archive upload is not finished")
+	}
+	if p.head.VerifyFiles {
+		for filename := range p.uncommitted {
+			return nil, fmt.Errorf("%w: %s", errFileNotCommitted, filename)
+		}
+	}
+	p.span.End()
+	newState, err = newRunningState(ctx, p.head, p.cleanPath, p.opts, p.record, p.logger)
 	if err == nil {
+		// the running state owns the workspace now.
 		p.cleanPath = false
+		p.record = nil
 	}
 	return newState, err
 }
@@ -77,16 +237,22 @@ func (p *preparingState) Output() <-chan *stateOutput {
 }
 
 func (p *preparingState) Close() error {
+	p.span.End()
+	if p.archive != nil {
+		p.archive.Abort()
+		p.archive = nil
+	}
 	if p.cleanPath {
 		err := os.RemoveAll(p.head.Path)
 		if err != nil {
 			return fmt.Errorf("failed to remove dir: %w", err)
 		}
 	}
+	p.record.Remove()
 	return nil
 }
 
-func newPreparingState(head *protocol.SpawnRequest_Head) (*preparingState, error) {
+func newPreparingState(ctx context.Context, head *protocol.SpawnRequest_Head, opts *options) (*preparingState, error) {
 	// creating cwd
 	cleanPath := false
 	if head.Path == "" {
@@ -97,9 +263,21 @@ func newPreparingState(head *protocol.SpawnRequest_Head) (*preparingState, error
 		}
 		head.Path = tmpDir
 	}
+	record := opts.journal.newRecord()
+	if cleanPath {
+		record.Update(func(entry *journalEntry) {
+			entry.Workspace = head.Path
+		})
+	}
 
+	_, span := tracing.Tracer().Start(ctx, "preparing", trace.WithAttributes(attribute.String("command", head.Command)))
 	return &preparingState{
-		head:      head,
-		cleanPath: cleanPath,
+		opts:        opts,
+		head:        head,
+		cleanPath:   cleanPath,
+		record:      record,
+		logger:      opts.logger.With("command", head.Command),
+		span:        span,
+		uncommitted: make(map[string]struct{}),
 	}, nil
 }
@@ -0,0 +1,55 @@
+package process
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/reyoung/rce/protocol"
+	"testing"
+)
+
+func fileEvent(file *protocol.SpawnRequest_File) *protocol.SpawnRequest {
+	return &protocol.SpawnRequest{Payload: &protocol.SpawnRequest_File_{File: file}}
+}
+
+func TestPreparingStateVerifyFiles(t *testing.T) {
+	ctx := context.Background()
+	p, err := newPreparingState(context.Background(), &protocol.SpawnRequest_Head{Path: t.TempDir(), VerifyFiles: true}, newOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	digest := sha256.Sum256([]byte("hello"))
+
+	_, err = p.ProcessEvent(ctx, fileEvent(&protocol.SpawnRequest_File{Filename: "a", Content: []byte("hello"), Truncate: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.ProcessEvent(ctx, &protocol.SpawnRequest{Payload: &protocol.SpawnRequest_Start_{}})
+	if !errors.Is(err, errFileNotCommitted) {
+		t.Fatalf("expect file not committed, got %v", err)
+	}
+
+	// a retried upload in append mode duplicates the content.
+	_, err = p.ProcessEvent(ctx, fileEvent(&protocol.SpawnRequest_File{Filename: "a", Content: []byte("hello")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.ProcessEvent(ctx, fileEvent(&protocol.SpawnRequest_File{Filename: "a", Eof: true, Size: 5}))
+	if !errors.Is(err, errFileSizeMismatch) {
+		t.Fatalf("expect size mismatch, got %v", err)
+	}
+
+	_, err = p.ProcessEvent(ctx, fileEvent(&protocol.SpawnRequest_File{
+		Filename: "b", Content: []byte("world"), Truncate: true, Eof: true, Sha256: hex.EncodeToString(digest[:])}))
+	if !errors.Is(err, errFileDigestMismatch) {
+		t.Fatalf("expect digest mismatch, got %v", err)
+	}
+
+	_, err = p.ProcessEvent(ctx, fileEvent(&protocol.SpawnRequest_File{
+		Filename: "c", Content: []byte("hello"), Truncate: true, Eof: true, Size: 5, Sha256: hex.EncodeToString(digest[:])}))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"github.com/reyoung/rce/protocol"
 	"sync"
+	"syscall"
 )
 
 type process struct {
+	// mutex guards curState and stateOutputChan, which are switched by the
+	// loop, and read by the callers of Process.
+	mutex           sync.Mutex
 	curState        state
 	reqChan         chan *protocol.SpawnRequest
 	rspChan         chan *protocol.SpawnResponse
@@ -16,8 +20,16 @@ type process struct {
 	complete        sync.WaitGroup
 }
 
+// current returns the current state and its output.
+func (p *process) current() (state, <-chan *stateOutput) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.curState, p.stateOutputChan
+}
+
 func (p *process) PID() string {
-	pid, ok := p.curState.(withPID)
+	cur, _ := p.current()
+	pid, ok := cur.(withPID)
 	if !ok {
 		return ""
 	}
@@ -25,13 +37,23 @@ func (p *process) PID() string {
 }
 
 func (p *process) Kill() error {
-	k, ok := p.curState.(withKill)
+	cur, _ := p.current()
+	k, ok := cur.(withKill)
 	if !ok {
 		return fmt.Errorf("kill not supported in current state")
 	}
 	return k.Kill()
 }
 
+func (p *process) Signal(sig syscall.Signal) error {
+	cur, _ := p.current()
+	k, ok := cur.(withKill)
+	if !ok {
+		return fmt.Errorf("signal not supported in current state")
+	}
+	return k.Signal(sig)
+}
+
 func (p *process) RequestChan() chan<- *protocol.SpawnRequest {
 	return p.reqChan
 }
@@ -77,8 +99,10 @@ func (p *process) switchState(newState state) (exit bool) {
 		}
 	}
 
+	p.mutex.Lock()
 	p.stateOutputChan = newState.Output()
 	p.curState = newState
+	p.mutex.Unlock()
 	return false
 }
 
@@ -102,7 +126,9 @@ func (p *process) step(ctx context.Context) (exit bool) {
 
 	case output, ok := <-p.stateOutputChan:
 		if !ok { // read all state output, then p.stateOutputChan can be nil
+			p.mutex.Lock()
 			p.stateOutputChan = nil
+			p.mutex.Unlock()
 			return false
 		}
 		exit = p.processStateOutput(output)
@@ -127,15 +153,19 @@ func (p *process) wait() {
 func (p *process) Close() error {
 	close(p.reqChan)
 	_ = p.Kill()
-	err := p.curState.Close()
-	for range p.stateOutputChan {
+	cur, output := p.current()
+	err := cur.Close()
+	if output != nil { // nil once the loop has read all output.
+		for range output {
+		}
 	}
 	return err
 }
 
-func New(ctx context.Context) Process {
+func New(ctx context.Context, opts ...Option) Process {
+	o := newOptions(opts...)
 	p := &process{
-		curState: &initState{},
+		curState: &initState{opts: o},
 		reqChan:  make(chan *protocol.SpawnRequest),
 		rspChan:  make(chan *protocol.SpawnResponse),
 		errChan:  make(chan error),
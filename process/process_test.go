@@ -46,3 +46,52 @@ func TestProcess(t *testing.T) {
 	p.Kill()
 	p.wait()
 }
+
+// runHead runs head to exit, and returns the stdout and stderr.
+func runHead(t *testing.T, head *protocol.SpawnRequest_Head) (stdout, stderr string) {
+	p := New(context.Background())
+	defer p.Close()
+	go func() {
+		p.RequestChan() <- &protocol.SpawnRequest{Payload: &protocol.SpawnRequest_Head_{Head: head}}
+		p.RequestChan() <- &protocol.SpawnRequest{
+			Payload: &protocol.SpawnRequest_Start_{Start: &protocol.SpawnRequest_Start{}}}
+	}()
+	for {
+		select {
+		case rsp := <-p.ResponseChan():
+			stdout += string(rsp.GetStdout().GetStdout())
+			stderr += string(rsp.GetStderr().GetStderr())
+			if rsp.GetExit() != nil {
+				return stdout, stderr
+			}
+		case err, ok := <-p.ErrorChan():
+			if !ok {
+				t.Fatal("process exited without exit code")
+			}
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestProcessMergeStderr(t *testing.T) {
+	stdout, stderr := runHead(t, &protocol.SpawnRequest_Head{
+		Command:     "sh",
+		Args:        []string{"-c", "echo 1; echo 2 >&2; echo 3"},
+		MergeStderr: true,
+	})
+	if stdout != "1\n2\n3\n" || stderr != "" {
+		t.Fatalf("unexpected stdout %q, stderr %q", stdout, stderr)
+	}
+}
+
+func TestProcessPtySeparateStderr(t *testing.T) {
+	stdout, stderr := runHead(t, &protocol.SpawnRequest_Head{
+		Command:        "sh",
+		Args:           []string{"-c", "test -t 1 && echo tty; test -t 2 || echo pipe >&2"},
+		AllocatePty:    true,
+		SeparateStderr: true,
+	})
+	if stdout != "tty\r\n" || stderr != "pipe\n" {
+		t.Fatalf("unexpected stdout %q, stderr %q", stdout, stderr)
+	}
+}
@@ -0,0 +1,295 @@
+package process
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"golang.org/x/sys/unix"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// EnableSubreaper makes the current process the subreaper of its
+// descendants, so orphaned processes of a job are reparented to the server
+// instead of init, and they can be found and reaped when the job ends. The
+// orphans are reaped whenever they exit, the leaders of jobs are left to
+// Cmd.Wait.
+func EnableSubreaper() error {
+	err := unix.Prctl(unix.PR_SET_CHILD_SUBREAPER, 1, 0, 0, 0)
+	if err != nil {
+		return fmt.Errorf("failed to set child subreaper: %w", err)
+	}
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, unix.SIGCHLD)
+	go func() {
+		for {
+			select {
+			case <-sigs:
+			case <-leaderReaped:
+			}
+			reapOrphans()
+		}
+	}()
+	return nil
+}
+
+// jobLeaders are the running leaders of jobs, which are reaped by Cmd.Wait.
+// The read lock is held while a leader starts, so reapOrphans never takes it
+// for an orphan before it is registered.
+var jobLeaders struct {
+	sync.RWMutex
+	pids sync.Map
+}
+
+// leaderReaped wakes up the reaper after a leader is reaped, since the
+// orphans after an exited leader are not reaped until then.
+var leaderReaped = make(chan struct{}, 1)
+
+// startLeader starts the leader of a job by start, and registers it.
+func startLeader(cmd *exec.Cmd, start func() error) error {
+	jobLeaders.RLock()
+	defer jobLeaders.RUnlock()
+	err := start()
+	if err == nil {
+		jobLeaders.pids.Store(cmd.Process.Pid, struct{}{})
+	}
+	return err
+}
+
+// forgetLeader unregisters the leader pid after Cmd.Wait reaps it.
+func forgetLeader(pid int) {
+	jobLeaders.pids.Delete(pid)
+	select {
+	case leaderReaped <- struct{}{}:
+	default:
+	}
+}
+
+// siginfoPid returns si_pid of info, which is not exported by x/sys. It
+// follows signo, errno and code, aligned to a pointer.
+func siginfoPid(info *unix.Siginfo) int {
+	align := unsafe.Sizeof(uintptr(0))
+	offset := (3*unsafe.Sizeof(int32(0)) + align - 1) &^ (align - 1)
+	return int(*(*int32)(unsafe.Add(unsafe.Pointer(info), offset)))
+}
+
+// reapOrphans reaps the exited children which are not leaders of jobs.
+// Wait4(-1) would take the exit status of the leaders from Cmd.Wait, so each
+// exited child is peeked by waitid first. Reaping stops at an exited leader,
+// and continues after Cmd.Wait reaps it.
+func reapOrphans() {
+	jobLeaders.Lock()
+	defer jobLeaders.Unlock()
+	for {
+		var info unix.Siginfo
+		err := unix.Waitid(unix.P_ALL, 0, &info, unix.WEXITED|unix.WNOHANG|unix.WNOWAIT, nil)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		pid := siginfoPid(&info)
+		if err != nil || pid == 0 { // no children, or none exited.
+			return
+		}
+		if _, ok := jobLeaders.pids.Load(pid); ok {
+			return
+		}
+		_, _ = unix.Wait4(pid, nil, unix.WNOHANG, nil)
+	}
+}
+
+// waitExit waits until the process pid exits, without reaping it. So the
+// output pipes are still open and Cmd.Wait can reap it later.
+func waitExit(pid int) error {
+	for {
+		var info unix.Siginfo
+		err := unix.Waitid(unix.P_PID, pid, &info, unix.WEXITED|unix.WNOWAIT, nil)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		return err
+	}
+}
+
+type procStat struct {
+	pid     int
+	state   byte
+	ppid    int
+	session int
+	// startTime is the time the process started after boot, in clock ticks.
+	startTime uint64
+}
+
+// readProcStat parses /proc/<pid>/stat.
+func readProcStat(pid int) (st procStat, err error) {
+	buf, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return st, err
+	}
+	// comm may contain spaces and parentheses, fields follow the last ')'.
+	i := bytes.LastIndexByte(buf, ')')
+	if i < 0 {
+		return st, fmt.Errorf("invalid stat of process %d", pid)
+	}
+	fields := bytes.Fields(buf[i+1:])
+	if len(fields) < 20 {
+		return st, fmt.Errorf("invalid stat of process %d", pid)
+	}
+	st.pid = pid
+	st.state = fields[0][0]
+	st.ppid, err = strconv.Atoi(string(fields[1]))
+	if err != nil {
+		return st, fmt.Errorf("invalid stat of process %d: %w", pid, err)
+	}
+	st.session, err = strconv.Atoi(string(fields[3]))
+	if err != nil {
+		return st, fmt.Errorf("invalid stat of process %d: %w", pid, err)
+	}
+	st.startTime, err = strconv.ParseUint(string(fields[19]), 10, 64)
+	if err != nil {
+		return st, fmt.Errorf("invalid stat of process %d: %w", pid, err)
+	}
+	return st, nil
+}
+
+// processStartTime returns the start time of process pid, which identifies
+// the process together with the boot id.
+func processStartTime(pid int) (uint64, error) {
+	st, err := readProcStat(pid)
+	if err != nil {
+		return 0, err
+	}
+	return st.startTime, nil
+}
+
+// bootID returns the id of the current boot, empty if unknown.
+func bootID() string {
+	buf, err := os.ReadFile("/proc/sys/kernel/random/boot_id")
+	if err != nil {
+		return ""
+	}
+	return string(bytes.TrimSpace(buf))
+}
+
+func killProcessGroup(pgid int) error {
+	return unix.Kill(-pgid, unix.SIGKILL)
+}
+
+// jobIDEnv is set to the id of the job in the environment of its leader.
+// It is inherited by the descendants of the job, which are found by it after
+// they leave the session and are reparented.
+const jobIDEnv = "RCE_JOB_ID"
+
+func listProcesses() ([]procStat, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	var procs []procStat
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		st, err := readProcStat(pid)
+		if err != nil { // exited
+			continue
+		}
+		procs = append(procs, st)
+	}
+	return procs, nil
+}
+
+// hasJobID returns true if the environment of process pid has jobID.
+func hasJobID(pid int, jobID string) bool {
+	buf, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/environ")
+	if err != nil {
+		return false
+	}
+	marker := []byte(jobIDEnv + "=" + jobID)
+	for _, env := range bytes.Split(buf, []byte{0}) {
+		if bytes.Equal(env, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// jobProcesses lists the processes of the job led by sid except the leader:
+// the processes in session sid or with jobID in their environment, and all
+// their descendants, which may have left the session by setsid.
+func jobProcesses(sid int, jobID string) ([]procStat, error) {
+	all, err := listProcesses()
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[int][]procStat)
+	var pending []procStat
+	for _, p := range all {
+		children[p.ppid] = append(children[p.ppid], p)
+		if p.pid != sid && (p.session == sid || (jobID != "" && hasJobID(p.pid, jobID))) {
+			pending = append(pending, p)
+		}
+	}
+	pending = append(pending, children[sid]...)
+	found := make(map[int]struct{})
+	var procs []procStat
+	for len(pending) > 0 {
+		p := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if _, ok := found[p.pid]; ok || p.pid == sid {
+			continue
+		}
+		found[p.pid] = struct{}{}
+		procs = append(procs, p)
+		pending = append(pending, children[p.pid]...)
+	}
+	return procs, nil
+}
+
+// reapTimeout limits the time of killing the processes of a job, a process
+// in uninterruptible sleep can not be killed.
+const reapTimeout = 10 * time.Second
+
+// reapJob kills every process left by the job led by sid, except the leader
+// which is reaped by Cmd.Wait. Zombies reparented to the server are reaped.
+func reapJob(sid int, jobID string, logger *slog.Logger) error {
+	self := os.Getpid()
+	killed := make(map[int]struct{})
+	deadline := time.Now().Add(reapTimeout)
+	for {
+		procs, err := jobProcesses(sid, jobID)
+		if err != nil {
+			return err
+		}
+		alive, reaped := 0, 0
+		for _, p := range procs {
+			if p.state == 'Z' {
+				if p.ppid == self {
+					// children of the reaped zombie are reparented, scan again.
+					_, _ = unix.Wait4(p.pid, nil, unix.WNOHANG, nil)
+					reaped++
+				}
+				continue
+			}
+			alive++
+			if _, ok := killed[p.pid]; !ok {
+				killed[p.pid] = struct{}{}
+				logger.Info("Killing orphan process", "os_pid", p.pid, "session", p.session)
+			}
+			_ = unix.Kill(p.pid, unix.SIGKILL)
+		}
+		if alive == 0 && reaped == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("failed to kill %d processes of job %d", alive, sid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
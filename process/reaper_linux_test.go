@@ -0,0 +1,170 @@
+package process
+
+import (
+	"context"
+	"github.com/reyoung/rce/protocol"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestProcessReapSession(t *testing.T) {
+	for _, pty := range []bool{false, true} {
+		start := time.Now()
+		// the orphan holds stdout, the job can not finish until it is killed.
+		stdout, _ := runHead(t, &protocol.SpawnRequest_Head{
+			Command:     "sh",
+			Args:        []string{"-c", "sleep 30 & echo $!"},
+			AllocatePty: pty,
+		})
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("job with pty %v is not finished until the orphan exits", pty)
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(stdout))
+		if err != nil {
+			t.Fatal(err)
+		}
+		st, err := readProcStat(pid)
+		if err == nil && st.state != 'Z' {
+			t.Fatalf("orphan %d of job with pty %v is alive", pid, pty)
+		}
+	}
+}
+
+func TestProcessReapEscaped(t *testing.T) {
+	start := time.Now()
+	// the first one leaves the session, the second one is also orphaned.
+	stdout, _ := runHead(t, &protocol.SpawnRequest_Head{
+		Command: "sh",
+		Args: []string{"-c", "setsid sh -c 'echo $$; exec sleep 30' & " +
+			"(setsid sh -c 'echo $$; exec sleep 30' &); sleep 0.5"},
+	})
+	if time.Since(start) > 10*time.Second {
+		t.Fatal("job is not finished until the escaped processes exit")
+	}
+	pids := strings.Fields(stdout)
+	if len(pids) != 2 {
+		t.Fatalf("unexpected stdout %q", stdout)
+	}
+	for _, s := range pids {
+		pid, err := strconv.Atoi(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		st, err := readProcStat(pid)
+		if err == nil && st.state != 'Z' {
+			t.Fatalf("escaped process %d is alive", pid)
+		}
+	}
+}
+
+func TestReapOrphans(t *testing.T) {
+	// the exited leader is found first, it blocks reaping until it is reaped.
+	leader := exec.Command("true")
+	if err := startLeader(leader, leader.Start); err != nil {
+		t.Fatal(err)
+	}
+	orphan := exec.Command("true")
+	if err := orphan.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for _, pid := range []int{orphan.Process.Pid, leader.Process.Pid} {
+		for {
+			st, err := readProcStat(pid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if st.state == 'Z' {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	reapOrphans()
+	if err := leader.Wait(); err != nil {
+		t.Fatalf("leader is reaped by the orphan reaper, %v", err)
+	}
+	forgetLeader(leader.Process.Pid)
+	reapOrphans()
+	if _, err := readProcStat(orphan.Process.Pid); !os.IsNotExist(err) {
+		t.Fatalf("orphan is not reaped, %v", err)
+	}
+}
+
+func TestJournalRecover(t *testing.T) {
+	j, err := OpenJournal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	workspace := t.TempDir()
+	cmd := exec.Command("sleep", "30")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err = cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	pid := cmd.Process.Pid
+	record := j.newRecord()
+	record.Update(func(entry *journalEntry) {
+		entry.Workspace = workspace
+		entry.Pgid = pid
+		entry.BootID = bootID()
+		entry.StartTime, _ = processStartTime(pid)
+	})
+
+	// the spill file of the crashed job.
+	if err = os.WriteFile(path.Join(j.dir, "rce-output-1"+spillSuffix), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = j.Recover(); err != nil {
+		t.Fatal(err)
+	}
+	if err = cmd.Wait(); err == nil {
+		t.Fatal("leftover process is not killed")
+	}
+	if _, err = os.Stat(workspace); !os.IsNotExist(err) {
+		t.Fatalf("leftover workspace is not removed, %v", err)
+	}
+	if entries, _ := os.ReadDir(j.dir); len(entries) != 0 {
+		t.Fatalf("journal is not cleaned, %d entries", len(entries))
+	}
+}
+
+func TestJournalCleanedAfterJob(t *testing.T) {
+	j, err := OpenJournal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := New(context.Background(), WithJournal(j))
+	go func() {
+		p.RequestChan() <- &protocol.SpawnRequest{Payload: &protocol.SpawnRequest_Head_{
+			Head: &protocol.SpawnRequest_Head{Command: "sh", Args: []string{"-c", "pwd; read line"}, HasStdin: true}}}
+		p.RequestChan() <- &protocol.SpawnRequest{
+			Payload: &protocol.SpawnRequest_Start_{Start: &protocol.SpawnRequest_Start{}}}
+	}()
+	for rsp := range p.ResponseChan() {
+		if rsp.GetStdout() != nil {
+			// the process waits for stdin, so the job is not ended.
+			if entries, _ := os.ReadDir(j.dir); len(entries) != 1 {
+				t.Fatalf("expect the job recorded, got %d entries", len(entries))
+			}
+			go func() {
+				p.RequestChan() <- &protocol.SpawnRequest{
+					Payload: &protocol.SpawnRequest_Stdin_{Stdin: &protocol.SpawnRequest_Stdin{Eof: true}}}
+			}()
+		}
+		if rsp.GetExit() != nil {
+			break
+		}
+	}
+	p.Close()
+	if entries, _ := os.ReadDir(j.dir); len(entries) != 0 {
+		t.Fatalf("journal is not cleaned, %d entries", len(entries))
+	}
+}
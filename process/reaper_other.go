@@ -0,0 +1,43 @@
+//go:build !linux
+
+package process
+
+import (
+	"errors"
+	"log/slog"
+	"os/exec"
+)
+
+var errReaperUnsupported = errors.New("reaper is only supported on linux")
+
+func EnableSubreaper() error {
+	return errReaperUnsupported
+}
+
+func waitExit(pid int) error {
+	return errReaperUnsupported
+}
+
+const jobIDEnv = "RCE_JOB_ID"
+
+func startLeader(cmd *exec.Cmd, start func() error) error {
+	return start()
+}
+
+func forgetLeader(pid int) {}
+
+func reapJob(sid int, jobID string, logger *slog.Logger) error {
+	return errReaperUnsupported
+}
+
+func processStartTime(pid int) (uint64, error) {
+	return 0, errReaperUnsupported
+}
+
+func bootID() string {
+	return ""
+}
+
+func killProcessGroup(pgid int) error {
+	return errReaperUnsupported
+}
@@ -7,8 +7,12 @@ import (
 	"github.com/creack/pty"
 	"github.com/google/uuid"
 	"github.com/reyoung/rce/protocol"
+	"github.com/reyoung/rce/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"sync"
@@ -24,6 +28,11 @@ type runningState struct {
 	Stderr     io.ReadCloser
 	ID         string
 	Complete   sync.WaitGroup
+	output     *outputBuffer
+	logger     *slog.Logger
+	// span lasts until the process exits.
+	span        trace.Span
+	firstOutput sync.Once
 }
 
 func (s *runningState) PID() string {
@@ -31,16 +40,20 @@ func (s *runningState) PID() string {
 }
 
 func (s *runningState) Kill() error {
-	log.Printf("Killing process")
+	s.logger.Debug("Killing process")
+	return s.Signal(syscall.SIGKILL)
+}
+
+// Signal sends sig to the process group of the process.
+func (s *runningState) Signal(sig syscall.Signal) error {
 	p := s.Cmd.Process
 	if p == nil {
-		log.Printf("process not started")
 		return fmt.Errorf("process not started")
 	}
 
-	err := syscall.Kill(-p.Pid, syscall.SIGKILL)
+	err := syscall.Kill(-p.Pid, sig)
 	if err != nil {
-		return fmt.Errorf("failed to kill process: %w", err)
+		return fmt.Errorf("failed to send %s to process: %w", sig, err)
 	}
 	return err
 }
@@ -74,7 +87,7 @@ func (s *runningState) processStdin(stdin *protocol.SpawnRequest_Stdin) error {
 		if err != nil {
 			return fmt.Errorf("failed to close stdin: %w", err)
 		}
-		log.Printf("stdin closed")
+		s.logger.Debug("Stdin closed")
 		s.Stdin = nil
 	}
 	return nil
@@ -83,6 +96,7 @@ func (s *runningState) processStdin(stdin *protocol.SpawnRequest_Stdin) error {
 func (s *runningState) Close() error {
 	var res error
 	if p := s.Cmd.Process; p != nil {
+		// the rest of the session is reaped when the leader exits.
 		err := p.Kill()
 		if err != nil && !errors.Is(err, os.ErrProcessDone) {
 			res = errors.Join(res, fmt.Errorf("failed to kill process: %w", err))
@@ -94,6 +108,9 @@ func (s *runningState) Close() error {
 
 	s.Complete.Wait()
 	close(s.OutputChan)
+	if s.output != nil {
+		res = errors.Join(res, s.output.Release())
+	}
 	return res
 }
 
@@ -102,19 +119,21 @@ func (s *runningState) Output() <-chan *stateOutput {
 }
 
 func (s *runningState) waitDone() {
-	defer func() {
-		log.Printf("waitDone done")
-	}()
 	err := s.Cmd.Wait()
-	log.Printf("waitDone err: %v", err)
+	s.logger.Info("Process exited", "state", s.Cmd.ProcessState.String(), "err", err)
+	if s.Cmd.ProcessState != nil {
+		s.span.SetAttributes(attribute.Int("exit_code", s.Cmd.ProcessState.ExitCode()))
+	}
 	if err != nil {
 		exitErr, ok := err.(*exec.ExitError)
 		if ok {
+			exit := &protocol.SpawnResponse_Exit{Code: int32(exitErr.ExitCode())}
+			if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+				exit.Signal = int32(ws.Signal())
+			}
 			s.OutputChan <- &stateOutput{
 				Response: &protocol.SpawnResponse{
-					Payload: &protocol.SpawnResponse_Exit_{
-						Exit: &protocol.SpawnResponse_Exit{Code: int32(exitErr.ExitCode())},
-					},
+					Payload: &protocol.SpawnResponse_Exit_{Exit: exit},
 				},
 			}
 		} else {
@@ -136,90 +155,220 @@ func (s *runningState) waitDone() {
 	}
 }
 
-const readBufSize = 4096
+const (
+	minReadBufSize = 4096
+	maxReadBufSize = 256 * 1024
+	// coalesceWindow is the max latency of coalescing small writes.
+	coalesceWindow = 2 * time.Millisecond
+)
 
-func (s *runningState) readOutput(reader io.ReadCloser, newResponse func([]byte) *protocol.SpawnResponse) {
+// readChunk is the data of a read, and the time it is read.
+type readChunk struct {
+	data     []byte
+	captured time.Time
+}
+
+// readChunks reads reader into chunks. The read buffer grows when reads fill
+// it and shrinks when reads are small, so that large outputs are read in
+// large chunks.
+func readChunks(reader io.Reader, chunks chan<- readChunk) error {
+	defer close(chunks)
+	bufSize := minReadBufSize
+	for {
+		buf := make([]byte, bufSize)
+		n, err := reader.Read(buf)
+		if n > 0 {
+			// stamped here, the chunk may wait in the channel.
+			chunks <- readChunk{data: buf[:n], captured: time.Now()}
+		}
+		if err != nil {
+			return err
+		}
+		if n == bufSize && bufSize < maxReadBufSize {
+			bufSize *= 2
+		} else if n < bufSize/4 && bufSize > minReadBufSize {
+			bufSize /= 2
+		}
+	}
+}
+
+func (s *runningState) readOutput(reader io.ReadCloser, stderr bool) {
 	defer func() {
 		_ = reader.Close()
 	}()
-	var buf [readBufSize]byte
-	for {
-		n, err := reader.Read(buf[:])
-		if n > 0 {
-			s.OutputChan <- &stateOutput{
-				Response: newResponse(buf[:n]),
+	chunks := make(chan readChunk, 16)
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- readChunks(reader, chunks)
+	}()
+
+	var pending []byte
+	var captured time.Time
+	var window <-chan time.Time
+	flush := func() {
+		if len(pending) > 0 {
+			s.firstOutput.Do(func() {
+				s.span.AddEvent("first output")
+			})
+			err := s.output.Push(outputChunk{stderr: stderr, timestamp: captured.UnixNano(), data: pending})
+			if err != nil {
+				s.OutputChan <- &stateOutput{Error: err}
 			}
 		}
-		if err != nil {
-			if !errors.Is(err, io.EOF) {
-				s.OutputChan <- &stateOutput{
-					Error: fmt.Errorf("failed to read output: %w", err),
+		pending = nil
+		window = nil
+	}
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				flush()
+				err := <-readErr
+				if !errors.Is(err, io.EOF) {
+					s.OutputChan <- &stateOutput{
+						Error: fmt.Errorf("failed to read output: %w", err),
+					}
 				}
+				return
+			}
+			// coalesce small writes within the window.
+			if pending == nil {
+				pending = chunk.data
+				captured = chunk.captured
+			} else {
+				pending = append(pending, chunk.data...)
+			}
+			if len(pending) >= maxReadBufSize {
+				flush()
+			} else if window == nil {
+				window = time.After(coalesceWindow)
 			}
-			break
+		case <-window:
+			flush()
 		}
 	}
 }
 
-func (s *runningState) startIOGoRoutines(cleanPath string) {
+func (s *runningState) startIOGoRoutines(cleanPath string, record *journalRecord) {
+	var outputs sync.WaitGroup
+	outputs.Add(1)
+	go func() {
+		defer outputs.Done()
+		s.readOutput(s.Stdout, false)
+	}()
+	if s.Stderr != nil {
+		outputs.Add(1)
+		go func() {
+			defer outputs.Done()
+			s.readOutput(s.Stderr, true)
+		}()
+	}
+
+	var pump sync.WaitGroup
+	pump.Add(1)
+	go func() {
+		defer pump.Done()
+		for {
+			output, ok := s.output.Pop()
+			if !ok {
+				return
+			}
+			s.OutputChan <- output
+		}
+	}()
+
 	s.Complete.Add(1)
 	go func() {
 		defer s.Complete.Done()
+		// the process leads its own session, its descendants are killed when
+		// it exits, otherwise they may hold the output pipes forever.
+		pid := s.Cmd.Process.Pid
+		if waitExit(pid) == nil {
+			s.span.AddEvent("exited")
+			err := reapJob(pid, s.ID, s.logger)
+			if err != nil {
+				s.logger.Warn("Failed to reap job", "err", err)
+			}
+		}
+		// Cmd.Wait closes the pipes, and the exit event must be the last output.
+		// So all output must be read and sent before waiting.
+		outputs.Wait()
+		s.output.Close()
+		pump.Wait()
 		s.waitDone()
-		log.Printf("cleanPath: %s", cleanPath)
+		forgetLeader(pid)
+		s.span.End()
 
+		// the first try is synchronous, so the workspace is removed when
+		// the state is closed, e.g. on server shutdown.
+		_, cleanup := tracing.Tracer().Start(trace.ContextWithSpan(context.Background(), s.span), "cleanup")
+		err := os.RemoveAll(cleanPath)
+		if err == nil {
+			record.Remove()
+			cleanup.End()
+			return
+		}
+		cleanup.RecordError(err)
+		cleanup.End()
+		s.logger.Warn("Failed to remove workspace, retry later", "dir", cleanPath, "err", err)
 		go func() {
 			for {
-				log.Printf("cleanPath from here: %s", cleanPath)
+				time.Sleep(time.Minute)
 				err := os.RemoveAll(cleanPath)
 				if err != nil {
-					log.Printf("failed to remove dir: %s", err)
-					time.Sleep(time.Minute)
+					s.logger.Warn("Failed to remove workspace, retry later", "dir", cleanPath, "err", err)
 					continue
 				}
+				record.Remove()
 				break
 			}
-
 		}()
 	}()
+}
 
-	s.Complete.Add(1)
-	go func() {
-		defer s.Complete.Done()
-		s.readOutput(s.Stdout, func(buf []byte) *protocol.SpawnResponse {
-			return &protocol.SpawnResponse{Payload: &protocol.SpawnResponse_Stdout_{
-				Stdout: &protocol.SpawnResponse_Stdout{Stdout: append([]byte(nil), buf...)}}}
-		})
-	}()
-	if s.Stderr != nil {
-		s.Complete.Add(1)
-		go func() {
-			defer s.Complete.Done()
-			s.readOutput(s.Stderr, func(bytes []byte) *protocol.SpawnResponse {
-				return &protocol.SpawnResponse{Payload: &protocol.SpawnResponse_Stderr_{
-					Stderr: &protocol.SpawnResponse_Stderr{Stderr: append([]byte(nil), bytes...)},
-				}}
-			})
-		}()
+// outputBufferSize returns the size of the output buffer requested by head,
+// limited by the server options.
+func outputBufferSize(head *protocol.SpawnRequest_Head, opts *options) int {
+	size := int(min(head.GetOutputBuffer().GetSize(), uint64(opts.outputBufferMax)))
+	if size == 0 {
+		size = opts.outputBufferSize
 	}
+	return size
 }
 
-func newRunningState(ctx context.Context, head *protocol.SpawnRequest_Head, cleanPath bool) (s *runningState, err error) {
+func newRunningState(ctx context.Context, head *protocol.SpawnRequest_Head, cleanPath bool,
+	opts *options, record *journalRecord, logger *slog.Logger) (s *runningState, err error) {
 	cmd := exec.CommandContext(ctx, head.Command, head.Args...)
 	cmd.Dir = head.Path
 	cmd.Env = append([]string(nil), os.Environ()...)
 	for _, env := range head.Envs {
 		cmd.Env = append(cmd.Env, env.Key+"="+env.Value)
 	}
+	id := uuid.New().String()
+	// set last, it is not overridden by the job.
+	cmd.Env = append(cmd.Env, jobIDEnv+"="+id)
 
+	// the spill file is bounded by the max size of the output buffer.
+	output := newOutputBuffer(outputBufferSize(head, opts), head.GetOutputBuffer().GetPolicy(),
+		opts.journal.spillDir(), opts.outputBufferMax)
 	s = &runningState{
-		Cmd: cmd,
+		Cmd:    cmd,
+		output: output,
+		logger: logger,
 	}
 	defer func(s *runningState) {
 		if err != nil {
 			err = errors.Join(err, s.Close())
 		}
 	}(s)
+	_, start := tracing.Tracer().Start(ctx, "start")
+	defer func() {
+		if err != nil {
+			start.RecordError(err)
+			start.SetStatus(codes.Error, err.Error())
+		}
+		start.End()
+	}()
 
 	outChan := make(chan *stateOutput, 1)
 	s.OutputChan = outChan
@@ -232,9 +381,19 @@ func newRunningState(ctx context.Context, head *protocol.SpawnRequest_Head, clea
 		if row == 0 {
 			row = 80
 		}
-		log.Printf("Starting command with pty, cols: %d, rows: %d", col, row)
+		logger.Debug("Starting command with pty", "cols", col, "rows", row)
+		if head.SeparateStderr {
+			// pty only connects the fds which are not set.
+			s.Stderr, err = cmd.StderrPipe()
+			if err != nil {
+				return nil, err
+			}
+		}
 		var pty_ *os.File
-		pty_, err = pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(col), Rows: uint16(row)})
+		err = startLeader(cmd, func() (err error) {
+			pty_, err = pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(col), Rows: uint16(row)})
+			return err
+		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to start command with pty: %w", err)
 		}
@@ -251,20 +410,32 @@ func newRunningState(ctx context.Context, head *protocol.SpawnRequest_Head, clea
 		}()
 
 		s.Stdout = pr
-		s.Stderr = nil
 		s.Stdin = pw2
 	} else {
+		// like pty.Start, the process leads a new session and process group.
 		cmd.SysProcAttr = &syscall.SysProcAttr{
-			Setpgid: true,
-			Pgid:    0,
+			Setsid: true,
 		}
-		s.Stdout, err = cmd.StdoutPipe()
-		if err != nil {
-			return nil, err
-		}
-		s.Stderr, err = cmd.StderrPipe()
-		if err != nil {
-			return nil, err
+		if head.MergeStderr {
+			var pr, pw *os.File
+			pr, pw, err = os.Pipe()
+			if err != nil {
+				return nil, fmt.Errorf("failed to create output pipe: %w", err)
+			}
+			// the child has its own copy after start.
+			defer pw.Close()
+			cmd.Stdout = pw
+			cmd.Stderr = pw
+			s.Stdout = pr
+		} else {
+			s.Stdout, err = cmd.StdoutPipe()
+			if err != nil {
+				return nil, err
+			}
+			s.Stderr, err = cmd.StderrPipe()
+			if err != nil {
+				return nil, err
+			}
 		}
 		if head.HasStdin {
 			s.Stdin, err = cmd.StdinPipe()
@@ -272,14 +443,26 @@ func newRunningState(ctx context.Context, head *protocol.SpawnRequest_Head, clea
 				return nil, err
 			}
 		}
-		err = cmd.Start()
-		log.Printf("Start process %d", cmd.Process.Pid)
-
+		err = startLeader(cmd, cmd.Start)
 		if err != nil {
+			if head.MergeStderr {
+				_ = s.Stdout.Close()
+			}
 			return nil, fmt.Errorf("failed to start command: %w", err)
 		}
 	}
-	s.ID = uuid.New().String()
+	pid := cmd.Process.Pid
+	record.Update(func(entry *journalEntry) {
+		entry.Pgid = pid
+		entry.BootID = bootID()
+		entry.StartTime, _ = processStartTime(pid)
+		entry.JobID = id
+	})
+	s.ID = id
+	s.logger = logger.With("pid", s.ID)
+	s.logger.Info("Process started", "os_pid", pid, "pty", head.AllocatePty)
+	_, s.span = tracing.Tracer().Start(ctx, "run", trace.WithAttributes(
+		attribute.String("pid", s.ID), attribute.Int("os_pid", pid)))
 	outChan <- &stateOutput{
 		Response: &protocol.SpawnResponse{
 			Payload: &protocol.SpawnResponse_Pid{
@@ -292,7 +475,7 @@ func newRunningState(ctx context.Context, head *protocol.SpawnRequest_Head, clea
 		cleanPathStr = head.Path
 	}
 
-	s.startIOGoRoutines(cleanPathStr)
+	s.startIOGoRoutines(cleanPathStr, record)
 
 	return s, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"github.com/reyoung/rce/protocol"
+	"syscall"
 )
 
 var (
@@ -32,4 +33,5 @@ type withPID interface {
 
 type withKill interface {
 	Kill() error
+	Signal(sig syscall.Signal) error
 }
@@ -0,0 +1,6 @@
+package protocol
+
+// NotStartedTrailer is a trailer of Spawn which is set to "true" if the
+// server ends the stream before the command may start, so the client can
+// safely spawn the command again.
+const NotStartedTrailer = "rce-not-started"
@@ -20,6 +20,187 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Feature is an optional feature of the protocol supported by the server.
+type Feature int32
+
+const (
+	Feature_FEATURE_UNSPECIFIED Feature = 0
+	Feature_FEATURE_PTY         Feature = 1
+	// FEATURE_FILE_CACHE is set if the file cache is enabled.
+	Feature_FEATURE_FILE_CACHE      Feature = 2
+	Feature_FEATURE_ARCHIVE         Feature = 3
+	Feature_FEATURE_FILE_METADATA   Feature = 4
+	Feature_FEATURE_OUTPUT_BUFFER   Feature = 5
+	Feature_FEATURE_TIMESTAMPS      Feature = 6
+	Feature_FEATURE_MERGE_STDERR    Feature = 7
+	Feature_FEATURE_SEPARATE_STDERR Feature = 8
+	Feature_FEATURE_PRIORITY        Feature = 9
+	Feature_FEATURE_DRAIN           Feature = 10
+	// FEATURE_SIGNAL is set if Kill sends the signal of the request.
+	Feature_FEATURE_SIGNAL Feature = 11
+)
+
+// Enum value maps for Feature.
+var (
+	Feature_name = map[int32]string{
+		0:  "FEATURE_UNSPECIFIED",
+		1:  "FEATURE_PTY",
+		2:  "FEATURE_FILE_CACHE",
+		3:  "FEATURE_ARCHIVE",
+		4:  "FEATURE_FILE_METADATA",
+		5:  "FEATURE_OUTPUT_BUFFER",
+		6:  "FEATURE_TIMESTAMPS",
+		7:  "FEATURE_MERGE_STDERR",
+		8:  "FEATURE_SEPARATE_STDERR",
+		9:  "FEATURE_PRIORITY",
+		10: "FEATURE_DRAIN",
+		11: "FEATURE_SIGNAL",
+	}
+	Feature_value = map[string]int32{
+		"FEATURE_UNSPECIFIED":     0,
+		"FEATURE_PTY":             1,
+		"FEATURE_FILE_CACHE":      2,
+		"FEATURE_ARCHIVE":         3,
+		"FEATURE_FILE_METADATA":   4,
+		"FEATURE_OUTPUT_BUFFER":   5,
+		"FEATURE_TIMESTAMPS":      6,
+		"FEATURE_MERGE_STDERR":    7,
+		"FEATURE_SEPARATE_STDERR": 8,
+		"FEATURE_PRIORITY":        9,
+		"FEATURE_DRAIN":           10,
+		"FEATURE_SIGNAL":          11,
+	}
+)
+
+func (x Feature) Enum() *Feature {
+	p := new(Feature)
+	*p = x
+	return p
+}
+
+func (x Feature) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Feature) Descriptor() protoreflect.EnumDescriptor {
+	return file_rce_proto_enumTypes[0].Descriptor()
+}
+
+func (Feature) Type() protoreflect.EnumType {
+	return &file_rce_proto_enumTypes[0]
+}
+
+func (x Feature) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Feature.Descriptor instead.
+func (Feature) EnumDescriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{0}
+}
+
+type SpawnRequest_Head_OutputBuffer_OverflowPolicy int32
+
+const (
+	// BLOCK stops reading the output, so the process blocks on writing.
+	SpawnRequest_Head_OutputBuffer_BLOCK SpawnRequest_Head_OutputBuffer_OverflowPolicy = 0
+	// DROP_OLDEST drops the oldest output, and sends a Gap response.
+	SpawnRequest_Head_OutputBuffer_DROP_OLDEST SpawnRequest_Head_OutputBuffer_OverflowPolicy = 1
+	// SPILL writes the output exceeding the buffer to disk, up to the max
+	// buffer size of the server, beyond which it blocks like BLOCK.
+	SpawnRequest_Head_OutputBuffer_SPILL SpawnRequest_Head_OutputBuffer_OverflowPolicy = 2
+)
+
+// Enum value maps for SpawnRequest_Head_OutputBuffer_OverflowPolicy.
+var (
+	SpawnRequest_Head_OutputBuffer_OverflowPolicy_name = map[int32]string{
+		0: "BLOCK",
+		1: "DROP_OLDEST",
+		2: "SPILL",
+	}
+	SpawnRequest_Head_OutputBuffer_OverflowPolicy_value = map[string]int32{
+		"BLOCK":       0,
+		"DROP_OLDEST": 1,
+		"SPILL":       2,
+	}
+)
+
+func (x SpawnRequest_Head_OutputBuffer_OverflowPolicy) Enum() *SpawnRequest_Head_OutputBuffer_OverflowPolicy {
+	p := new(SpawnRequest_Head_OutputBuffer_OverflowPolicy)
+	*p = x
+	return p
+}
+
+func (x SpawnRequest_Head_OutputBuffer_OverflowPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SpawnRequest_Head_OutputBuffer_OverflowPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_rce_proto_enumTypes[1].Descriptor()
+}
+
+func (SpawnRequest_Head_OutputBuffer_OverflowPolicy) Type() protoreflect.EnumType {
+	return &file_rce_proto_enumTypes[1]
+}
+
+func (x SpawnRequest_Head_OutputBuffer_OverflowPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SpawnRequest_Head_OutputBuffer_OverflowPolicy.Descriptor instead.
+func (SpawnRequest_Head_OutputBuffer_OverflowPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{2, 1, 1, 0}
+}
+
+type SpawnRequest_Archive_Compression int32
+
+const (
+	SpawnRequest_Archive_NONE SpawnRequest_Archive_Compression = 0
+	SpawnRequest_Archive_GZIP SpawnRequest_Archive_Compression = 1
+	SpawnRequest_Archive_ZSTD SpawnRequest_Archive_Compression = 2
+)
+
+// Enum value maps for SpawnRequest_Archive_Compression.
+var (
+	SpawnRequest_Archive_Compression_name = map[int32]string{
+		0: "NONE",
+		1: "GZIP",
+		2: "ZSTD",
+	}
+	SpawnRequest_Archive_Compression_value = map[string]int32{
+		"NONE": 0,
+		"GZIP": 1,
+		"ZSTD": 2,
+	}
+)
+
+func (x SpawnRequest_Archive_Compression) Enum() *SpawnRequest_Archive_Compression {
+	p := new(SpawnRequest_Archive_Compression)
+	*p = x
+	return p
+}
+
+func (x SpawnRequest_Archive_Compression) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SpawnRequest_Archive_Compression) Descriptor() protoreflect.EnumDescriptor {
+	return file_rce_proto_enumTypes[2].Descriptor()
+}
+
+func (SpawnRequest_Archive_Compression) Type() protoreflect.EnumType {
+	return &file_rce_proto_enumTypes[2]
+}
+
+func (x SpawnRequest_Archive_Compression) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SpawnRequest_Archive_Compression.Descriptor instead.
+func (SpawnRequest_Archive_Compression) EnumDescriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{2, 4, 0}
+}
+
 type WindowSize struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -75,24 +256,101 @@ func (x *WindowSize) GetCol() uint32 {
 	return 0
 }
 
+type FileMetadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// mode is the unix permission bits, including setuid, setgid and sticky.
+	// 0 keeps the default mode.
+	Mode uint32 `protobuf:"varint,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	// mtime in nanoseconds since unix epoch, 0 keeps the current time.
+	MtimeNs int64 `protobuf:"varint,2,opt,name=mtime_ns,json=mtimeNs,proto3" json:"mtime_ns,omitempty"`
+	// symlink_target creates a symlink instead of a regular file.
+	SymlinkTarget string `protobuf:"bytes,3,opt,name=symlink_target,json=symlinkTarget,proto3" json:"symlink_target,omitempty"`
+	// owner is only set if the ownership should be preserved.
+	Owner *FileMetadata_Owner `protobuf:"bytes,4,opt,name=owner,proto3" json:"owner,omitempty"`
+}
+
+func (x *FileMetadata) Reset() {
+	*x = FileMetadata{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FileMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileMetadata) ProtoMessage() {}
+
+func (x *FileMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileMetadata.ProtoReflect.Descriptor instead.
+func (*FileMetadata) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FileMetadata) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *FileMetadata) GetMtimeNs() int64 {
+	if x != nil {
+		return x.MtimeNs
+	}
+	return 0
+}
+
+func (x *FileMetadata) GetSymlinkTarget() string {
+	if x != nil {
+		return x.SymlinkTarget
+	}
+	return ""
+}
+
+func (x *FileMetadata) GetOwner() *FileMetadata_Owner {
+	if x != nil {
+		return x.Owner
+	}
+	return nil
+}
+
 type SpawnRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
 	// Types that are assignable to Payload:
-	//
 	//	*SpawnRequest_File_
 	//	*SpawnRequest_Head_
 	//	*SpawnRequest_Stdin_
 	//	*SpawnRequest_Start_
+	//	*SpawnRequest_Archive_
+	//	*SpawnRequest_CachedFile_
 	Payload isSpawnRequest_Payload `protobuf_oneof:"payload"`
 }
 
 func (x *SpawnRequest) Reset() {
 	*x = SpawnRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rce_proto_msgTypes[1]
+		mi := &file_rce_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -105,7 +363,7 @@ func (x *SpawnRequest) String() string {
 func (*SpawnRequest) ProtoMessage() {}
 
 func (x *SpawnRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_rce_proto_msgTypes[1]
+	mi := &file_rce_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -118,7 +376,7 @@ func (x *SpawnRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SpawnRequest.ProtoReflect.Descriptor instead.
 func (*SpawnRequest) Descriptor() ([]byte, []int) {
-	return file_rce_proto_rawDescGZIP(), []int{1}
+	return file_rce_proto_rawDescGZIP(), []int{2}
 }
 
 func (m *SpawnRequest) GetPayload() isSpawnRequest_Payload {
@@ -156,6 +414,20 @@ func (x *SpawnRequest) GetStart() *SpawnRequest_Start {
 	return nil
 }
 
+func (x *SpawnRequest) GetArchive() *SpawnRequest_Archive {
+	if x, ok := x.GetPayload().(*SpawnRequest_Archive_); ok {
+		return x.Archive
+	}
+	return nil
+}
+
+func (x *SpawnRequest) GetCachedFile() *SpawnRequest_CachedFile {
+	if x, ok := x.GetPayload().(*SpawnRequest_CachedFile_); ok {
+		return x.CachedFile
+	}
+	return nil
+}
+
 type isSpawnRequest_Payload interface {
 	isSpawnRequest_Payload()
 }
@@ -176,6 +448,14 @@ type SpawnRequest_Start_ struct {
 	Start *SpawnRequest_Start `protobuf:"bytes,4,opt,name=start,proto3,oneof"`
 }
 
+type SpawnRequest_Archive_ struct {
+	Archive *SpawnRequest_Archive `protobuf:"bytes,5,opt,name=archive,proto3,oneof"`
+}
+
+type SpawnRequest_CachedFile_ struct {
+	CachedFile *SpawnRequest_CachedFile `protobuf:"bytes,6,opt,name=cached_file,json=cachedFile,proto3,oneof"`
+}
+
 func (*SpawnRequest_File_) isSpawnRequest_Payload() {}
 
 func (*SpawnRequest_Head_) isSpawnRequest_Payload() {}
@@ -184,18 +464,24 @@ func (*SpawnRequest_Stdin_) isSpawnRequest_Payload() {}
 
 func (*SpawnRequest_Start_) isSpawnRequest_Payload() {}
 
+func (*SpawnRequest_Archive_) isSpawnRequest_Payload() {}
+
+func (*SpawnRequest_CachedFile_) isSpawnRequest_Payload() {}
+
 type PID struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
 	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// signal is sent by Kill to the process group, 0 means SIGKILL.
+	Signal int32 `protobuf:"varint,2,opt,name=signal,proto3" json:"signal,omitempty"`
 }
 
 func (x *PID) Reset() {
 	*x = PID{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rce_proto_msgTypes[2]
+		mi := &file_rce_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -208,7 +494,7 @@ func (x *PID) String() string {
 func (*PID) ProtoMessage() {}
 
 func (x *PID) ProtoReflect() protoreflect.Message {
-	mi := &file_rce_proto_msgTypes[2]
+	mi := &file_rce_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -221,7 +507,7 @@ func (x *PID) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use PID.ProtoReflect.Descriptor instead.
 func (*PID) Descriptor() ([]byte, []int) {
-	return file_rce_proto_rawDescGZIP(), []int{2}
+	return file_rce_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *PID) GetId() string {
@@ -231,25 +517,33 @@ func (x *PID) GetId() string {
 	return ""
 }
 
+func (x *PID) GetSignal() int32 {
+	if x != nil {
+		return x.Signal
+	}
+	return 0
+}
+
 type SpawnResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
 	// Types that are assignable to Payload:
-	//
 	//	*SpawnResponse_Stdout_
 	//	*SpawnResponse_Stderr_
 	//	*SpawnResponse_Exit_
 	//	*SpawnResponse_Pid
 	//	*SpawnResponse_Error
+	//	*SpawnResponse_Gap_
+	//	*SpawnResponse_Queued_
 	Payload isSpawnResponse_Payload `protobuf_oneof:"payload"`
 }
 
 func (x *SpawnResponse) Reset() {
 	*x = SpawnResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rce_proto_msgTypes[3]
+		mi := &file_rce_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -262,7 +556,7 @@ func (x *SpawnResponse) String() string {
 func (*SpawnResponse) ProtoMessage() {}
 
 func (x *SpawnResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_rce_proto_msgTypes[3]
+	mi := &file_rce_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -275,7 +569,7 @@ func (x *SpawnResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SpawnResponse.ProtoReflect.Descriptor instead.
 func (*SpawnResponse) Descriptor() ([]byte, []int) {
-	return file_rce_proto_rawDescGZIP(), []int{3}
+	return file_rce_proto_rawDescGZIP(), []int{4}
 }
 
 func (m *SpawnResponse) GetPayload() isSpawnResponse_Payload {
@@ -320,6 +614,20 @@ func (x *SpawnResponse) GetError() *SpawnResponse_SystemError {
 	return nil
 }
 
+func (x *SpawnResponse) GetGap() *SpawnResponse_Gap {
+	if x, ok := x.GetPayload().(*SpawnResponse_Gap_); ok {
+		return x.Gap
+	}
+	return nil
+}
+
+func (x *SpawnResponse) GetQueued() *SpawnResponse_Queued {
+	if x, ok := x.GetPayload().(*SpawnResponse_Queued_); ok {
+		return x.Queued
+	}
+	return nil
+}
+
 type isSpawnResponse_Payload interface {
 	isSpawnResponse_Payload()
 }
@@ -344,6 +652,14 @@ type SpawnResponse_Error struct {
 	Error *SpawnResponse_SystemError `protobuf:"bytes,5,opt,name=error,proto3,oneof"`
 }
 
+type SpawnResponse_Gap_ struct {
+	Gap *SpawnResponse_Gap `protobuf:"bytes,6,opt,name=gap,proto3,oneof"`
+}
+
+type SpawnResponse_Queued_ struct {
+	Queued *SpawnResponse_Queued `protobuf:"bytes,7,opt,name=queued,proto3,oneof"`
+}
+
 func (*SpawnResponse_Stdout_) isSpawnResponse_Payload() {}
 
 func (*SpawnResponse_Stderr_) isSpawnResponse_Payload() {}
@@ -354,6 +670,10 @@ func (*SpawnResponse_Pid) isSpawnResponse_Payload() {}
 
 func (*SpawnResponse_Error) isSpawnResponse_Payload() {}
 
+func (*SpawnResponse_Gap_) isSpawnResponse_Payload() {}
+
+func (*SpawnResponse_Queued_) isSpawnResponse_Payload() {}
+
 type KillResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -365,7 +685,7 @@ type KillResponse struct {
 func (x *KillResponse) Reset() {
 	*x = KillResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rce_proto_msgTypes[4]
+		mi := &file_rce_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -378,7 +698,7 @@ func (x *KillResponse) String() string {
 func (*KillResponse) ProtoMessage() {}
 
 func (x *KillResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_rce_proto_msgTypes[4]
+	mi := &file_rce_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -391,7 +711,7 @@ func (x *KillResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use KillResponse.ProtoReflect.Descriptor instead.
 func (*KillResponse) Descriptor() ([]byte, []int) {
-	return file_rce_proto_rawDescGZIP(), []int{4}
+	return file_rce_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *KillResponse) GetError() string {
@@ -401,34 +721,31 @@ func (x *KillResponse) GetError() string {
 	return ""
 }
 
-type SpawnRequest_File struct {
+type FindMissingBlobsRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Filename   string `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
-	Content    []byte `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
-	Executable bool   `protobuf:"varint,3,opt,name=executable,proto3" json:"executable,omitempty"`
-	Truncate   bool   `protobuf:"varint,4,opt,name=truncate,proto3" json:"truncate,omitempty"`
+	Sha256 []string `protobuf:"bytes,1,rep,name=sha256,proto3" json:"sha256,omitempty"`
 }
 
-func (x *SpawnRequest_File) Reset() {
-	*x = SpawnRequest_File{}
+func (x *FindMissingBlobsRequest) Reset() {
+	*x = FindMissingBlobsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rce_proto_msgTypes[5]
+		mi := &file_rce_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SpawnRequest_File) String() string {
+func (x *FindMissingBlobsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SpawnRequest_File) ProtoMessage() {}
+func (*FindMissingBlobsRequest) ProtoMessage() {}
 
-func (x *SpawnRequest_File) ProtoReflect() protoreflect.Message {
-	mi := &file_rce_proto_msgTypes[5]
+func (x *FindMissingBlobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -439,70 +756,101 @@ func (x *SpawnRequest_File) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SpawnRequest_File.ProtoReflect.Descriptor instead.
-func (*SpawnRequest_File) Descriptor() ([]byte, []int) {
-	return file_rce_proto_rawDescGZIP(), []int{1, 0}
+// Deprecated: Use FindMissingBlobsRequest.ProtoReflect.Descriptor instead.
+func (*FindMissingBlobsRequest) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *SpawnRequest_File) GetFilename() string {
+func (x *FindMissingBlobsRequest) GetSha256() []string {
 	if x != nil {
-		return x.Filename
+		return x.Sha256
 	}
-	return ""
+	return nil
 }
 
-func (x *SpawnRequest_File) GetContent() []byte {
-	if x != nil {
-		return x.Content
+type FindMissingBlobsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Missing []string `protobuf:"bytes,1,rep,name=missing,proto3" json:"missing,omitempty"`
+	Error   string   `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *FindMissingBlobsResponse) Reset() {
+	*x = FindMissingBlobsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
 	}
-	return nil
 }
 
-func (x *SpawnRequest_File) GetExecutable() bool {
+func (x *FindMissingBlobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindMissingBlobsResponse) ProtoMessage() {}
+
+func (x *FindMissingBlobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindMissingBlobsResponse.ProtoReflect.Descriptor instead.
+func (*FindMissingBlobsResponse) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *FindMissingBlobsResponse) GetMissing() []string {
 	if x != nil {
-		return x.Executable
+		return x.Missing
 	}
-	return false
+	return nil
 }
 
-func (x *SpawnRequest_File) GetTruncate() bool {
+func (x *FindMissingBlobsResponse) GetError() string {
 	if x != nil {
-		return x.Truncate
+		return x.Error
 	}
-	return false
+	return ""
 }
 
-type SpawnRequest_Head struct {
+// PutBlobRequest uploads a blob into the file cache. The sha256 of the blob
+// must be set in the first frame.
+type PutBlobRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Command     string                   `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
-	Args        []string                 `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
-	Envs        []*SpawnRequest_Head_Env `protobuf:"bytes,3,rep,name=envs,proto3" json:"envs,omitempty"`
-	HasStdin    bool                     `protobuf:"varint,4,opt,name=has_stdin,json=hasStdin,proto3" json:"has_stdin,omitempty"`
-	Path        string                   `protobuf:"bytes,5,opt,name=path,proto3" json:"path,omitempty"`
-	AllocatePty bool                     `protobuf:"varint,6,opt,name=allocate_pty,json=allocatePty,proto3" json:"allocate_pty,omitempty"`
-	WindowSize  *WindowSize              `protobuf:"bytes,7,opt,name=window_size,json=windowSize,proto3" json:"window_size,omitempty"`
+	Sha256  string `protobuf:"bytes,1,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Content []byte `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
 }
 
-func (x *SpawnRequest_Head) Reset() {
-	*x = SpawnRequest_Head{}
+func (x *PutBlobRequest) Reset() {
+	*x = PutBlobRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rce_proto_msgTypes[6]
+		mi := &file_rce_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SpawnRequest_Head) String() string {
+func (x *PutBlobRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SpawnRequest_Head) ProtoMessage() {}
+func (*PutBlobRequest) ProtoMessage() {}
 
-func (x *SpawnRequest_Head) ProtoReflect() protoreflect.Message {
-	mi := &file_rce_proto_msgTypes[6]
+func (x *PutBlobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -513,83 +861,1019 @@ func (x *SpawnRequest_Head) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SpawnRequest_Head.ProtoReflect.Descriptor instead.
-func (*SpawnRequest_Head) Descriptor() ([]byte, []int) {
-	return file_rce_proto_rawDescGZIP(), []int{1, 1}
+// Deprecated: Use PutBlobRequest.ProtoReflect.Descriptor instead.
+func (*PutBlobRequest) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *SpawnRequest_Head) GetCommand() string {
+func (x *PutBlobRequest) GetSha256() string {
 	if x != nil {
-		return x.Command
+		return x.Sha256
 	}
 	return ""
 }
 
-func (x *SpawnRequest_Head) GetArgs() []string {
+func (x *PutBlobRequest) GetContent() []byte {
 	if x != nil {
-		return x.Args
+		return x.Content
 	}
 	return nil
 }
 
-func (x *SpawnRequest_Head) GetEnvs() []*SpawnRequest_Head_Env {
+type PutBlobResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *PutBlobResponse) Reset() {
+	*x = PutBlobResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PutBlobResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutBlobResponse) ProtoMessage() {}
+
+func (x *PutBlobResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutBlobResponse.ProtoReflect.Descriptor instead.
+func (*PutBlobResponse) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PutBlobResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// DrainRequest stops the server from accepting new jobs. If wait is set, it
+// returns when the running jobs end or the deadline of the call exceeds.
+type DrainRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Wait bool `protobuf:"varint,1,opt,name=wait,proto3" json:"wait,omitempty"`
+}
+
+func (x *DrainRequest) Reset() {
+	*x = DrainRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DrainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrainRequest) ProtoMessage() {}
+
+func (x *DrainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrainRequest.ProtoReflect.Descriptor instead.
+func (*DrainRequest) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DrainRequest) GetWait() bool {
+	if x != nil {
+		return x.Wait
+	}
+	return false
+}
+
+type DrainResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// running is the number of jobs which are not ended.
+	Running uint32 `protobuf:"varint,1,opt,name=running,proto3" json:"running,omitempty"`
+	Error   string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *DrainResponse) Reset() {
+	*x = DrainResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DrainResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DrainResponse) ProtoMessage() {}
+
+func (x *DrainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DrainResponse.ProtoReflect.Descriptor instead.
+func (*DrainResponse) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *DrainResponse) GetRunning() uint32 {
+	if x != nil {
+		return x.Running
+	}
+	return 0
+}
+
+func (x *DrainResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type InfoRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *InfoRequest) Reset() {
+	*x = InfoRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *InfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoRequest) ProtoMessage() {}
+
+func (x *InfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoRequest.ProtoReflect.Descriptor instead.
+func (*InfoRequest) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{12}
+}
+
+type InfoResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Version  string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Os       string `protobuf:"bytes,2,opt,name=os,proto3" json:"os,omitempty"`
+	Arch     string `protobuf:"bytes,3,opt,name=arch,proto3" json:"arch,omitempty"`
+	Hostname string `protobuf:"bytes,4,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	// shells are the login shells available on the server.
+	Shells   []string  `protobuf:"bytes,5,rep,name=shells,proto3" json:"shells,omitempty"`
+	Features []Feature `protobuf:"varint,6,rep,packed,name=features,proto3,enum=protocol.Feature" json:"features,omitempty"`
+	// compressors of responses in preference order.
+	Compressors []string             `protobuf:"bytes,7,rep,name=compressors,proto3" json:"compressors,omitempty"`
+	Limits      *InfoResponse_Limits `protobuf:"bytes,8,opt,name=limits,proto3" json:"limits,omitempty"`
+	Load        *InfoResponse_Load   `protobuf:"bytes,9,opt,name=load,proto3" json:"load,omitempty"`
+}
+
+func (x *InfoResponse) Reset() {
+	*x = InfoResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *InfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoResponse) ProtoMessage() {}
+
+func (x *InfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoResponse.ProtoReflect.Descriptor instead.
+func (*InfoResponse) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *InfoResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *InfoResponse) GetOs() string {
+	if x != nil {
+		return x.Os
+	}
+	return ""
+}
+
+func (x *InfoResponse) GetArch() string {
+	if x != nil {
+		return x.Arch
+	}
+	return ""
+}
+
+func (x *InfoResponse) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *InfoResponse) GetShells() []string {
+	if x != nil {
+		return x.Shells
+	}
+	return nil
+}
+
+func (x *InfoResponse) GetFeatures() []Feature {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+func (x *InfoResponse) GetCompressors() []string {
+	if x != nil {
+		return x.Compressors
+	}
+	return nil
+}
+
+func (x *InfoResponse) GetLimits() *InfoResponse_Limits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+func (x *InfoResponse) GetLoad() *InfoResponse_Load {
+	if x != nil {
+		return x.Load
+	}
+	return nil
+}
+
+type FileMetadata_Owner struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Uid uint32 `protobuf:"varint,1,opt,name=uid,proto3" json:"uid,omitempty"`
+	Gid uint32 `protobuf:"varint,2,opt,name=gid,proto3" json:"gid,omitempty"`
+}
+
+func (x *FileMetadata_Owner) Reset() {
+	*x = FileMetadata_Owner{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FileMetadata_Owner) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileMetadata_Owner) ProtoMessage() {}
+
+func (x *FileMetadata_Owner) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileMetadata_Owner.ProtoReflect.Descriptor instead.
+func (*FileMetadata_Owner) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{1, 0}
+}
+
+func (x *FileMetadata_Owner) GetUid() uint32 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+func (x *FileMetadata_Owner) GetGid() uint32 {
+	if x != nil {
+		return x.Gid
+	}
+	return 0
+}
+
+type SpawnRequest_File struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Filename   string `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Content    []byte `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Executable bool   `protobuf:"varint,3,opt,name=executable,proto3" json:"executable,omitempty"`
+	Truncate   bool   `protobuf:"varint,4,opt,name=truncate,proto3" json:"truncate,omitempty"`
+	// eof commits the file. The server verifies the size and sha256 of the
+	// file if they are set.
+	Eof    bool   `protobuf:"varint,5,opt,name=eof,proto3" json:"eof,omitempty"`
+	Size   uint64 `protobuf:"varint,6,opt,name=size,proto3" json:"size,omitempty"`
+	Sha256 string `protobuf:"bytes,7,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	// metadata is applied when the file is committed.
+	Metadata *FileMetadata `protobuf:"bytes,8,opt,name=metadata,proto3" json:"metadata,omitempty"`
+}
+
+func (x *SpawnRequest_File) Reset() {
+	*x = SpawnRequest_File{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpawnRequest_File) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpawnRequest_File) ProtoMessage() {}
+
+func (x *SpawnRequest_File) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpawnRequest_File.ProtoReflect.Descriptor instead.
+func (*SpawnRequest_File) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{2, 0}
+}
+
+func (x *SpawnRequest_File) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *SpawnRequest_File) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *SpawnRequest_File) GetExecutable() bool {
+	if x != nil {
+		return x.Executable
+	}
+	return false
+}
+
+func (x *SpawnRequest_File) GetTruncate() bool {
+	if x != nil {
+		return x.Truncate
+	}
+	return false
+}
+
+func (x *SpawnRequest_File) GetEof() bool {
+	if x != nil {
+		return x.Eof
+	}
+	return false
+}
+
+func (x *SpawnRequest_File) GetSize() uint64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *SpawnRequest_File) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *SpawnRequest_File) GetMetadata() *FileMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type SpawnRequest_Head struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Command     string                   `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	Args        []string                 `protobuf:"bytes,2,rep,name=args,proto3" json:"args,omitempty"`
+	Envs        []*SpawnRequest_Head_Env `protobuf:"bytes,3,rep,name=envs,proto3" json:"envs,omitempty"`
+	HasStdin    bool                     `protobuf:"varint,4,opt,name=has_stdin,json=hasStdin,proto3" json:"has_stdin,omitempty"`
+	Path        string                   `protobuf:"bytes,5,opt,name=path,proto3" json:"path,omitempty"`
+	AllocatePty bool                     `protobuf:"varint,6,opt,name=allocate_pty,json=allocatePty,proto3" json:"allocate_pty,omitempty"`
+	WindowSize  *WindowSize              `protobuf:"bytes,7,opt,name=window_size,json=windowSize,proto3" json:"window_size,omitempty"`
+	// verify_files requires every uploaded file to be committed before start.
+	VerifyFiles  bool                            `protobuf:"varint,8,opt,name=verify_files,json=verifyFiles,proto3" json:"verify_files,omitempty"`
+	OutputBuffer *SpawnRequest_Head_OutputBuffer `protobuf:"bytes,9,opt,name=output_buffer,json=outputBuffer,proto3" json:"output_buffer,omitempty"`
+	// merge_stderr connects stdout and stderr of the process to one pipe, like
+	// 2>&1, so the output is sent as stdout in the order it is written.
+	// It is implied by allocate_pty.
+	MergeStderr bool `protobuf:"varint,10,opt,name=merge_stderr,json=mergeStderr,proto3" json:"merge_stderr,omitempty"`
+	// separate_stderr keeps stderr on a pipe when allocate_pty is set, the pty
+	// is only used for stdin and stdout.
+	SeparateStderr bool `protobuf:"varint,11,opt,name=separate_stderr,json=separateStderr,proto3" json:"separate_stderr,omitempty"`
+	// priority of the job in the server queue, higher starts first.
+	Priority int32 `protobuf:"varint,12,opt,name=priority,proto3" json:"priority,omitempty"`
+}
+
+func (x *SpawnRequest_Head) Reset() {
+	*x = SpawnRequest_Head{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpawnRequest_Head) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpawnRequest_Head) ProtoMessage() {}
+
+func (x *SpawnRequest_Head) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpawnRequest_Head.ProtoReflect.Descriptor instead.
+func (*SpawnRequest_Head) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{2, 1}
+}
+
+func (x *SpawnRequest_Head) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *SpawnRequest_Head) GetArgs() []string {
+	if x != nil {
+		return x.Args
+	}
+	return nil
+}
+
+func (x *SpawnRequest_Head) GetEnvs() []*SpawnRequest_Head_Env {
 	if x != nil {
 		return x.Envs
 	}
-	return nil
+	return nil
+}
+
+func (x *SpawnRequest_Head) GetHasStdin() bool {
+	if x != nil {
+		return x.HasStdin
+	}
+	return false
+}
+
+func (x *SpawnRequest_Head) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SpawnRequest_Head) GetAllocatePty() bool {
+	if x != nil {
+		return x.AllocatePty
+	}
+	return false
+}
+
+func (x *SpawnRequest_Head) GetWindowSize() *WindowSize {
+	if x != nil {
+		return x.WindowSize
+	}
+	return nil
+}
+
+func (x *SpawnRequest_Head) GetVerifyFiles() bool {
+	if x != nil {
+		return x.VerifyFiles
+	}
+	return false
+}
+
+func (x *SpawnRequest_Head) GetOutputBuffer() *SpawnRequest_Head_OutputBuffer {
+	if x != nil {
+		return x.OutputBuffer
+	}
+	return nil
+}
+
+func (x *SpawnRequest_Head) GetMergeStderr() bool {
+	if x != nil {
+		return x.MergeStderr
+	}
+	return false
+}
+
+func (x *SpawnRequest_Head) GetSeparateStderr() bool {
+	if x != nil {
+		return x.SeparateStderr
+	}
+	return false
+}
+
+func (x *SpawnRequest_Head) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+type SpawnRequest_Start struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *SpawnRequest_Start) Reset() {
+	*x = SpawnRequest_Start{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpawnRequest_Start) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpawnRequest_Start) ProtoMessage() {}
+
+func (x *SpawnRequest_Start) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpawnRequest_Start.ProtoReflect.Descriptor instead.
+func (*SpawnRequest_Start) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{2, 2}
+}
+
+type SpawnRequest_Stdin struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Stdin []byte `protobuf:"bytes,1,opt,name=stdin,proto3" json:"stdin,omitempty"`
+	Eof   bool   `protobuf:"varint,2,opt,name=eof,proto3" json:"eof,omitempty"`
+}
+
+func (x *SpawnRequest_Stdin) Reset() {
+	*x = SpawnRequest_Stdin{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpawnRequest_Stdin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpawnRequest_Stdin) ProtoMessage() {}
+
+func (x *SpawnRequest_Stdin) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpawnRequest_Stdin.ProtoReflect.Descriptor instead.
+func (*SpawnRequest_Stdin) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{2, 3}
+}
+
+func (x *SpawnRequest_Stdin) GetStdin() []byte {
+	if x != nil {
+		return x.Stdin
+	}
+	return nil
+}
+
+func (x *SpawnRequest_Stdin) GetEof() bool {
+	if x != nil {
+		return x.Eof
+	}
+	return false
+}
+
+// Archive is a chunk of a tar stream which will be extracted into path.
+// The stream is finished by a frame with eof set.
+type SpawnRequest_Archive struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Path          string                           `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Content       []byte                           `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Compression   SpawnRequest_Archive_Compression `protobuf:"varint,3,opt,name=compression,proto3,enum=protocol.SpawnRequest_Archive_Compression" json:"compression,omitempty"`
+	Eof           bool                             `protobuf:"varint,4,opt,name=eof,proto3" json:"eof,omitempty"`
+	PreserveOwner bool                             `protobuf:"varint,5,opt,name=preserve_owner,json=preserveOwner,proto3" json:"preserve_owner,omitempty"`
+}
+
+func (x *SpawnRequest_Archive) Reset() {
+	*x = SpawnRequest_Archive{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpawnRequest_Archive) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpawnRequest_Archive) ProtoMessage() {}
+
+func (x *SpawnRequest_Archive) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpawnRequest_Archive.ProtoReflect.Descriptor instead.
+func (*SpawnRequest_Archive) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{2, 4}
+}
+
+func (x *SpawnRequest_Archive) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *SpawnRequest_Archive) GetContent() []byte {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *SpawnRequest_Archive) GetCompression() SpawnRequest_Archive_Compression {
+	if x != nil {
+		return x.Compression
+	}
+	return SpawnRequest_Archive_NONE
+}
+
+func (x *SpawnRequest_Archive) GetEof() bool {
+	if x != nil {
+		return x.Eof
+	}
+	return false
+}
+
+func (x *SpawnRequest_Archive) GetPreserveOwner() bool {
+	if x != nil {
+		return x.PreserveOwner
+	}
+	return false
+}
+
+// CachedFile creates filename from a blob in the server side file cache.
+type SpawnRequest_CachedFile struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Filename   string        `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
+	Sha256     string        `protobuf:"bytes,2,opt,name=sha256,proto3" json:"sha256,omitempty"`
+	Executable bool          `protobuf:"varint,3,opt,name=executable,proto3" json:"executable,omitempty"`
+	Metadata   *FileMetadata `protobuf:"bytes,4,opt,name=metadata,proto3" json:"metadata,omitempty"`
+}
+
+func (x *SpawnRequest_CachedFile) Reset() {
+	*x = SpawnRequest_CachedFile{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpawnRequest_CachedFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpawnRequest_CachedFile) ProtoMessage() {}
+
+func (x *SpawnRequest_CachedFile) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpawnRequest_CachedFile.ProtoReflect.Descriptor instead.
+func (*SpawnRequest_CachedFile) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{2, 5}
+}
+
+func (x *SpawnRequest_CachedFile) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+func (x *SpawnRequest_CachedFile) GetSha256() string {
+	if x != nil {
+		return x.Sha256
+	}
+	return ""
+}
+
+func (x *SpawnRequest_CachedFile) GetExecutable() bool {
+	if x != nil {
+		return x.Executable
+	}
+	return false
+}
+
+func (x *SpawnRequest_CachedFile) GetMetadata() *FileMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type SpawnRequest_Head_Env struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *SpawnRequest_Head_Env) Reset() {
+	*x = SpawnRequest_Head_Env{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
 }
 
-func (x *SpawnRequest_Head) GetHasStdin() bool {
+func (x *SpawnRequest_Head_Env) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpawnRequest_Head_Env) ProtoMessage() {}
+
+func (x *SpawnRequest_Head_Env) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpawnRequest_Head_Env.ProtoReflect.Descriptor instead.
+func (*SpawnRequest_Head_Env) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{2, 1, 0}
+}
+
+func (x *SpawnRequest_Head_Env) GetKey() string {
 	if x != nil {
-		return x.HasStdin
+		return x.Key
 	}
-	return false
+	return ""
 }
 
-func (x *SpawnRequest_Head) GetPath() string {
+func (x *SpawnRequest_Head_Env) GetValue() string {
 	if x != nil {
-		return x.Path
+		return x.Value
 	}
 	return ""
 }
 
-func (x *SpawnRequest_Head) GetAllocatePty() bool {
+// OutputBuffer buffers output of the process for slow clients.
+type SpawnRequest_Head_OutputBuffer struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// size in bytes, 0 uses the server default.
+	Size   uint64                                        `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	Policy SpawnRequest_Head_OutputBuffer_OverflowPolicy `protobuf:"varint,2,opt,name=policy,proto3,enum=protocol.SpawnRequest_Head_OutputBuffer_OverflowPolicy" json:"policy,omitempty"`
+}
+
+func (x *SpawnRequest_Head_OutputBuffer) Reset() {
+	*x = SpawnRequest_Head_OutputBuffer{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpawnRequest_Head_OutputBuffer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpawnRequest_Head_OutputBuffer) ProtoMessage() {}
+
+func (x *SpawnRequest_Head_OutputBuffer) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpawnRequest_Head_OutputBuffer.ProtoReflect.Descriptor instead.
+func (*SpawnRequest_Head_OutputBuffer) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{2, 1, 1}
+}
+
+func (x *SpawnRequest_Head_OutputBuffer) GetSize() uint64 {
 	if x != nil {
-		return x.AllocatePty
+		return x.Size
 	}
-	return false
+	return 0
 }
 
-func (x *SpawnRequest_Head) GetWindowSize() *WindowSize {
+func (x *SpawnRequest_Head_OutputBuffer) GetPolicy() SpawnRequest_Head_OutputBuffer_OverflowPolicy {
 	if x != nil {
-		return x.WindowSize
+		return x.Policy
 	}
-	return nil
+	return SpawnRequest_Head_OutputBuffer_BLOCK
 }
 
-type SpawnRequest_Start struct {
+// sequence numbers stdout and stderr frames of a process in one monotonic
+// order, starting from 1. timestamp_ns is the unix time in nanoseconds when
+// the server captured the output.
+type SpawnResponse_Stdout struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+
+	Stdout      []byte `protobuf:"bytes,1,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	Sequence    uint64 `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	TimestampNs int64  `protobuf:"varint,3,opt,name=timestamp_ns,json=timestampNs,proto3" json:"timestamp_ns,omitempty"`
 }
 
-func (x *SpawnRequest_Start) Reset() {
-	*x = SpawnRequest_Start{}
+func (x *SpawnResponse_Stdout) Reset() {
+	*x = SpawnResponse_Stdout{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rce_proto_msgTypes[7]
+		mi := &file_rce_proto_msgTypes[23]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SpawnRequest_Start) String() string {
+func (x *SpawnResponse_Stdout) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SpawnRequest_Start) ProtoMessage() {}
+func (*SpawnResponse_Stdout) ProtoMessage() {}
 
-func (x *SpawnRequest_Start) ProtoReflect() protoreflect.Message {
-	mi := &file_rce_proto_msgTypes[7]
+func (x *SpawnResponse_Stdout) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[23]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -600,37 +1884,59 @@ func (x *SpawnRequest_Start) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SpawnRequest_Start.ProtoReflect.Descriptor instead.
-func (*SpawnRequest_Start) Descriptor() ([]byte, []int) {
-	return file_rce_proto_rawDescGZIP(), []int{1, 2}
+// Deprecated: Use SpawnResponse_Stdout.ProtoReflect.Descriptor instead.
+func (*SpawnResponse_Stdout) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{4, 0}
 }
 
-type SpawnRequest_Stdin struct {
+func (x *SpawnResponse_Stdout) GetStdout() []byte {
+	if x != nil {
+		return x.Stdout
+	}
+	return nil
+}
+
+func (x *SpawnResponse_Stdout) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *SpawnResponse_Stdout) GetTimestampNs() int64 {
+	if x != nil {
+		return x.TimestampNs
+	}
+	return 0
+}
+
+type SpawnResponse_Stderr struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Stdin []byte `protobuf:"bytes,1,opt,name=stdin,proto3" json:"stdin,omitempty"`
-	Eof   bool   `protobuf:"varint,2,opt,name=eof,proto3" json:"eof,omitempty"`
+	Stderr      []byte `protobuf:"bytes,1,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	Sequence    uint64 `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	TimestampNs int64  `protobuf:"varint,3,opt,name=timestamp_ns,json=timestampNs,proto3" json:"timestamp_ns,omitempty"`
 }
 
-func (x *SpawnRequest_Stdin) Reset() {
-	*x = SpawnRequest_Stdin{}
+func (x *SpawnResponse_Stderr) Reset() {
+	*x = SpawnResponse_Stderr{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rce_proto_msgTypes[8]
+		mi := &file_rce_proto_msgTypes[24]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SpawnRequest_Stdin) String() string {
+func (x *SpawnResponse_Stderr) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SpawnRequest_Stdin) ProtoMessage() {}
+func (*SpawnResponse_Stderr) ProtoMessage() {}
 
-func (x *SpawnRequest_Stdin) ProtoReflect() protoreflect.Message {
-	mi := &file_rce_proto_msgTypes[8]
+func (x *SpawnResponse_Stderr) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[24]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -641,51 +1947,60 @@ func (x *SpawnRequest_Stdin) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SpawnRequest_Stdin.ProtoReflect.Descriptor instead.
-func (*SpawnRequest_Stdin) Descriptor() ([]byte, []int) {
-	return file_rce_proto_rawDescGZIP(), []int{1, 3}
+// Deprecated: Use SpawnResponse_Stderr.ProtoReflect.Descriptor instead.
+func (*SpawnResponse_Stderr) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{4, 1}
 }
 
-func (x *SpawnRequest_Stdin) GetStdin() []byte {
+func (x *SpawnResponse_Stderr) GetStderr() []byte {
 	if x != nil {
-		return x.Stdin
+		return x.Stderr
 	}
 	return nil
 }
 
-func (x *SpawnRequest_Stdin) GetEof() bool {
+func (x *SpawnResponse_Stderr) GetSequence() uint64 {
 	if x != nil {
-		return x.Eof
+		return x.Sequence
 	}
-	return false
+	return 0
 }
 
-type SpawnRequest_Head_Env struct {
+func (x *SpawnResponse_Stderr) GetTimestampNs() int64 {
+	if x != nil {
+		return x.TimestampNs
+	}
+	return 0
+}
+
+type SpawnResponse_Exit struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
-	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	// code is -1 if the process is killed by a signal.
+	Code int32 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	// signal which killed the process, 0 if the process exited.
+	Signal int32 `protobuf:"varint,2,opt,name=signal,proto3" json:"signal,omitempty"`
 }
 
-func (x *SpawnRequest_Head_Env) Reset() {
-	*x = SpawnRequest_Head_Env{}
+func (x *SpawnResponse_Exit) Reset() {
+	*x = SpawnResponse_Exit{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rce_proto_msgTypes[9]
+		mi := &file_rce_proto_msgTypes[25]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SpawnRequest_Head_Env) String() string {
+func (x *SpawnResponse_Exit) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SpawnRequest_Head_Env) ProtoMessage() {}
+func (*SpawnResponse_Exit) ProtoMessage() {}
 
-func (x *SpawnRequest_Head_Env) ProtoReflect() protoreflect.Message {
-	mi := &file_rce_proto_msgTypes[9]
+func (x *SpawnResponse_Exit) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[25]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -696,50 +2011,99 @@ func (x *SpawnRequest_Head_Env) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SpawnRequest_Head_Env.ProtoReflect.Descriptor instead.
-func (*SpawnRequest_Head_Env) Descriptor() ([]byte, []int) {
-	return file_rce_proto_rawDescGZIP(), []int{1, 1, 0}
+// Deprecated: Use SpawnResponse_Exit.ProtoReflect.Descriptor instead.
+func (*SpawnResponse_Exit) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{4, 2}
 }
 
-func (x *SpawnRequest_Head_Env) GetKey() string {
+func (x *SpawnResponse_Exit) GetCode() int32 {
 	if x != nil {
-		return x.Key
+		return x.Code
 	}
-	return ""
+	return 0
 }
 
-func (x *SpawnRequest_Head_Env) GetValue() string {
+func (x *SpawnResponse_Exit) GetSignal() int32 {
 	if x != nil {
-		return x.Value
+		return x.Signal
+	}
+	return 0
+}
+
+type SpawnResponse_SystemError struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *SpawnResponse_SystemError) Reset() {
+	*x = SpawnResponse_SystemError{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rce_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpawnResponse_SystemError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpawnResponse_SystemError) ProtoMessage() {}
+
+func (x *SpawnResponse_SystemError) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpawnResponse_SystemError.ProtoReflect.Descriptor instead.
+func (*SpawnResponse_SystemError) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{4, 3}
+}
+
+func (x *SpawnResponse_SystemError) GetError() string {
+	if x != nil {
+		return x.Error
 	}
 	return ""
 }
 
-type SpawnResponse_Stdout struct {
+// Gap reports output dropped by the server before the next output.
+type SpawnResponse_Gap struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Stdout []byte `protobuf:"bytes,1,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	StdoutBytes uint64 `protobuf:"varint,1,opt,name=stdout_bytes,json=stdoutBytes,proto3" json:"stdout_bytes,omitempty"`
+	StderrBytes uint64 `protobuf:"varint,2,opt,name=stderr_bytes,json=stderrBytes,proto3" json:"stderr_bytes,omitempty"`
 }
 
-func (x *SpawnResponse_Stdout) Reset() {
-	*x = SpawnResponse_Stdout{}
+func (x *SpawnResponse_Gap) Reset() {
+	*x = SpawnResponse_Gap{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rce_proto_msgTypes[10]
+		mi := &file_rce_proto_msgTypes[27]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SpawnResponse_Stdout) String() string {
+func (x *SpawnResponse_Gap) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SpawnResponse_Stdout) ProtoMessage() {}
+func (*SpawnResponse_Gap) ProtoMessage() {}
 
-func (x *SpawnResponse_Stdout) ProtoReflect() protoreflect.Message {
-	mi := &file_rce_proto_msgTypes[10]
+func (x *SpawnResponse_Gap) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[27]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -750,43 +2114,52 @@ func (x *SpawnResponse_Stdout) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SpawnResponse_Stdout.ProtoReflect.Descriptor instead.
-func (*SpawnResponse_Stdout) Descriptor() ([]byte, []int) {
-	return file_rce_proto_rawDescGZIP(), []int{3, 0}
+// Deprecated: Use SpawnResponse_Gap.ProtoReflect.Descriptor instead.
+func (*SpawnResponse_Gap) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{4, 4}
 }
 
-func (x *SpawnResponse_Stdout) GetStdout() []byte {
+func (x *SpawnResponse_Gap) GetStdoutBytes() uint64 {
 	if x != nil {
-		return x.Stdout
+		return x.StdoutBytes
 	}
-	return nil
+	return 0
 }
 
-type SpawnResponse_Stderr struct {
+func (x *SpawnResponse_Gap) GetStderrBytes() uint64 {
+	if x != nil {
+		return x.StderrBytes
+	}
+	return 0
+}
+
+// Queued reports the position of the job in the server queue after Start,
+// it is sent again when the position changes. position starts from 1.
+type SpawnResponse_Queued struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Stderr []byte `protobuf:"bytes,1,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	Position uint32 `protobuf:"varint,1,opt,name=position,proto3" json:"position,omitempty"`
 }
 
-func (x *SpawnResponse_Stderr) Reset() {
-	*x = SpawnResponse_Stderr{}
+func (x *SpawnResponse_Queued) Reset() {
+	*x = SpawnResponse_Queued{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rce_proto_msgTypes[11]
+		mi := &file_rce_proto_msgTypes[28]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SpawnResponse_Stderr) String() string {
+func (x *SpawnResponse_Queued) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SpawnResponse_Stderr) ProtoMessage() {}
+func (*SpawnResponse_Queued) ProtoMessage() {}
 
-func (x *SpawnResponse_Stderr) ProtoReflect() protoreflect.Message {
-	mi := &file_rce_proto_msgTypes[11]
+func (x *SpawnResponse_Queued) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[28]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -797,43 +2170,47 @@ func (x *SpawnResponse_Stderr) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SpawnResponse_Stderr.ProtoReflect.Descriptor instead.
-func (*SpawnResponse_Stderr) Descriptor() ([]byte, []int) {
-	return file_rce_proto_rawDescGZIP(), []int{3, 1}
+// Deprecated: Use SpawnResponse_Queued.ProtoReflect.Descriptor instead.
+func (*SpawnResponse_Queued) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{4, 5}
 }
 
-func (x *SpawnResponse_Stderr) GetStderr() []byte {
+func (x *SpawnResponse_Queued) GetPosition() uint32 {
 	if x != nil {
-		return x.Stderr
+		return x.Position
 	}
-	return nil
+	return 0
 }
 
-type SpawnResponse_Exit struct {
+// Limits are the configured maxima, zero means unlimited.
+type InfoResponse_Limits struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Code int32 `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	MaxRunning          uint32 `protobuf:"varint,1,opt,name=max_running,json=maxRunning,proto3" json:"max_running,omitempty"`
+	MaxRunningPerCaller uint32 `protobuf:"varint,2,opt,name=max_running_per_caller,json=maxRunningPerCaller,proto3" json:"max_running_per_caller,omitempty"`
+	OutputBufferSize    uint64 `protobuf:"varint,3,opt,name=output_buffer_size,json=outputBufferSize,proto3" json:"output_buffer_size,omitempty"`
+	OutputBufferMax     uint64 `protobuf:"varint,4,opt,name=output_buffer_max,json=outputBufferMax,proto3" json:"output_buffer_max,omitempty"`
 }
 
-func (x *SpawnResponse_Exit) Reset() {
-	*x = SpawnResponse_Exit{}
+func (x *InfoResponse_Limits) Reset() {
+	*x = InfoResponse_Limits{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rce_proto_msgTypes[12]
+		mi := &file_rce_proto_msgTypes[29]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SpawnResponse_Exit) String() string {
+func (x *InfoResponse_Limits) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SpawnResponse_Exit) ProtoMessage() {}
+func (*InfoResponse_Limits) ProtoMessage() {}
 
-func (x *SpawnResponse_Exit) ProtoReflect() protoreflect.Message {
-	mi := &file_rce_proto_msgTypes[12]
+func (x *InfoResponse_Limits) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[29]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -844,43 +2221,68 @@ func (x *SpawnResponse_Exit) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SpawnResponse_Exit.ProtoReflect.Descriptor instead.
-func (*SpawnResponse_Exit) Descriptor() ([]byte, []int) {
-	return file_rce_proto_rawDescGZIP(), []int{3, 2}
+// Deprecated: Use InfoResponse_Limits.ProtoReflect.Descriptor instead.
+func (*InfoResponse_Limits) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{13, 0}
 }
 
-func (x *SpawnResponse_Exit) GetCode() int32 {
+func (x *InfoResponse_Limits) GetMaxRunning() uint32 {
 	if x != nil {
-		return x.Code
+		return x.MaxRunning
 	}
 	return 0
 }
 
-type SpawnResponse_SystemError struct {
+func (x *InfoResponse_Limits) GetMaxRunningPerCaller() uint32 {
+	if x != nil {
+		return x.MaxRunningPerCaller
+	}
+	return 0
+}
+
+func (x *InfoResponse_Limits) GetOutputBufferSize() uint64 {
+	if x != nil {
+		return x.OutputBufferSize
+	}
+	return 0
+}
+
+func (x *InfoResponse_Limits) GetOutputBufferMax() uint64 {
+	if x != nil {
+		return x.OutputBufferMax
+	}
+	return 0
+}
+
+type InfoResponse_Load struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	Running  uint32 `protobuf:"varint,1,opt,name=running,proto3" json:"running,omitempty"`
+	Queued   uint32 `protobuf:"varint,2,opt,name=queued,proto3" json:"queued,omitempty"`
+	Draining bool   `protobuf:"varint,3,opt,name=draining,proto3" json:"draining,omitempty"`
+	// load_average is the 1 minute load average of the host, zero if unknown.
+	LoadAverage float64 `protobuf:"fixed64,4,opt,name=load_average,json=loadAverage,proto3" json:"load_average,omitempty"`
 }
 
-func (x *SpawnResponse_SystemError) Reset() {
-	*x = SpawnResponse_SystemError{}
+func (x *InfoResponse_Load) Reset() {
+	*x = InfoResponse_Load{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_rce_proto_msgTypes[13]
+		mi := &file_rce_proto_msgTypes[30]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *SpawnResponse_SystemError) String() string {
+func (x *InfoResponse_Load) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SpawnResponse_SystemError) ProtoMessage() {}
+func (*InfoResponse_Load) ProtoMessage() {}
 
-func (x *SpawnResponse_SystemError) ProtoReflect() protoreflect.Message {
-	mi := &file_rce_proto_msgTypes[13]
+func (x *InfoResponse_Load) ProtoReflect() protoreflect.Message {
+	mi := &file_rce_proto_msgTypes[30]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -891,16 +2293,37 @@ func (x *SpawnResponse_SystemError) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SpawnResponse_SystemError.ProtoReflect.Descriptor instead.
-func (*SpawnResponse_SystemError) Descriptor() ([]byte, []int) {
-	return file_rce_proto_rawDescGZIP(), []int{3, 3}
+// Deprecated: Use InfoResponse_Load.ProtoReflect.Descriptor instead.
+func (*InfoResponse_Load) Descriptor() ([]byte, []int) {
+	return file_rce_proto_rawDescGZIP(), []int{13, 1}
 }
 
-func (x *SpawnResponse_SystemError) GetError() string {
+func (x *InfoResponse_Load) GetRunning() uint32 {
 	if x != nil {
-		return x.Error
+		return x.Running
 	}
-	return ""
+	return 0
+}
+
+func (x *InfoResponse_Load) GetQueued() uint32 {
+	if x != nil {
+		return x.Queued
+	}
+	return 0
+}
+
+func (x *InfoResponse_Load) GetDraining() bool {
+	if x != nil {
+		return x.Draining
+	}
+	return false
+}
+
+func (x *InfoResponse_Load) GetLoadAverage() float64 {
+	if x != nil {
+		return x.LoadAverage
+	}
+	return 0
 }
 
 var File_rce_proto protoreflect.FileDescriptor
@@ -910,93 +2333,291 @@ var file_rce_proto_rawDesc = []byte{
 	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x22, 0x30, 0x0a, 0x0a, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x53,
 	0x69, 0x7a, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x72, 0x6f, 0x77, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
 	0x52, 0x03, 0x72, 0x6f, 0x77, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x6f, 0x6c, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0d, 0x52, 0x03, 0x63, 0x6f, 0x6c, 0x22, 0xc5, 0x05, 0x0a, 0x0c, 0x53, 0x70, 0x61, 0x77,
-	0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x31, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
-	0x6c, 0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x46,
-	0x69, 0x6c, 0x65, 0x48, 0x00, 0x52, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x31, 0x0a, 0x04, 0x68,
-	0x65, 0x61, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x28, 0x0d, 0x52, 0x03, 0x63, 0x6f, 0x6c, 0x22, 0xc5, 0x01, 0x0a, 0x0c, 0x46, 0x69, 0x6c, 0x65,
+	0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x12, 0x19, 0x0a, 0x08,
+	0x6d, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07,
+	0x6d, 0x74, 0x69, 0x6d, 0x65, 0x4e, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x79, 0x6d, 0x6c, 0x69,
+	0x6e, 0x6b, 0x5f, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0d, 0x73, 0x79, 0x6d, 0x6c, 0x69, 0x6e, 0x6b, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x12, 0x32,
+	0x0a, 0x05, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0x2e, 0x4f, 0x77, 0x6e, 0x65, 0x72, 0x52, 0x05, 0x6f, 0x77, 0x6e,
+	0x65, 0x72, 0x1a, 0x2b, 0x0a, 0x05, 0x4f, 0x77, 0x6e, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x75,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x75, 0x69, 0x64, 0x12, 0x10, 0x0a,
+	0x03, 0x67, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x03, 0x67, 0x69, 0x64, 0x22,
+	0xc8, 0x0d, 0x0a, 0x0c, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x31, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x48, 0x00, 0x52, 0x04, 0x66,
+	0x69, 0x6c, 0x65, 0x12, 0x31, 0x0a, 0x04, 0x68, 0x65, 0x61, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70, 0x61,
+	0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x48, 0x00,
+	0x52, 0x04, 0x68, 0x65, 0x61, 0x64, 0x12, 0x34, 0x0a, 0x05, 0x73, 0x74, 0x64, 0x69, 0x6e, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x53, 0x74,
+	0x64, 0x69, 0x6e, 0x48, 0x00, 0x52, 0x05, 0x73, 0x74, 0x64, 0x69, 0x6e, 0x12, 0x34, 0x0a, 0x05,
+	0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x2e, 0x53, 0x74, 0x61, 0x72, 0x74, 0x48, 0x00, 0x52, 0x05, 0x73, 0x74, 0x61,
+	0x72, 0x74, 0x12, 0x3a, 0x0a, 0x07, 0x61, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53,
+	0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x41, 0x72, 0x63, 0x68,
+	0x69, 0x76, 0x65, 0x48, 0x00, 0x52, 0x07, 0x61, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x12, 0x44,
+	0x0a, 0x0b, 0x63, 0x61, 0x63, 0x68, 0x65, 0x64, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53,
+	0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x43, 0x61, 0x63, 0x68,
+	0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x48, 0x00, 0x52, 0x0a, 0x63, 0x61, 0x63, 0x68, 0x65, 0x64,
+	0x46, 0x69, 0x6c, 0x65, 0x1a, 0xea, 0x01, 0x0a, 0x04, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x1a, 0x0a,
+	0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e,
+	0x74, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74,
+	0x65, 0x6e, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x65, 0x78, 0x65, 0x63, 0x75, 0x74, 0x61, 0x62, 0x6c,
+	0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x65, 0x78, 0x65, 0x63, 0x75, 0x74, 0x61,
+	0x62, 0x6c, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x74, 0x72, 0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x74, 0x72, 0x75, 0x6e, 0x63, 0x61, 0x74, 0x65, 0x12,
+	0x10, 0x0a, 0x03, 0x65, 0x6f, 0x66, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x65, 0x6f,
+	0x66, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36, 0x12, 0x32, 0x0a,
+	0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x4d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
+	0x61, 0x1a, 0xac, 0x05, 0x0a, 0x04, 0x48, 0x65, 0x61, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f,
+	0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x6d,
+	0x6d, 0x61, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x73, 0x18, 0x02, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x12, 0x33, 0x0a, 0x04, 0x65, 0x6e, 0x76, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x48,
+	0x65, 0x61, 0x64, 0x2e, 0x45, 0x6e, 0x76, 0x52, 0x04, 0x65, 0x6e, 0x76, 0x73, 0x12, 0x1b, 0x0a,
+	0x09, 0x68, 0x61, 0x73, 0x5f, 0x73, 0x74, 0x64, 0x69, 0x6e, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x08, 0x68, 0x61, 0x73, 0x53, 0x74, 0x64, 0x69, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61,
+	0x74, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x21,
+	0x0a, 0x0c, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x70, 0x74, 0x79, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x50, 0x74,
+	0x79, 0x12, 0x35, 0x0a, 0x0b, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x5f, 0x73, 0x69, 0x7a, 0x65,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x2e, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x53, 0x69, 0x7a, 0x65, 0x52, 0x0a, 0x77, 0x69,
+	0x6e, 0x64, 0x6f, 0x77, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x76, 0x65, 0x72, 0x69,
+	0x66, 0x79, 0x5f, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b,
+	0x76, 0x65, 0x72, 0x69, 0x66, 0x79, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x4d, 0x0a, 0x0d, 0x6f,
+	0x75, 0x74, 0x70, 0x75, 0x74, 0x5f, 0x62, 0x75, 0x66, 0x66, 0x65, 0x72, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x28, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70,
+	0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x2e,
+	0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x42, 0x75, 0x66, 0x66, 0x65, 0x72, 0x52, 0x0c, 0x6f, 0x75,
+	0x74, 0x70, 0x75, 0x74, 0x42, 0x75, 0x66, 0x66, 0x65, 0x72, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x65,
+	0x72, 0x67, 0x65, 0x5f, 0x73, 0x74, 0x64, 0x65, 0x72, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0b, 0x6d, 0x65, 0x72, 0x67, 0x65, 0x53, 0x74, 0x64, 0x65, 0x72, 0x72, 0x12, 0x27, 0x0a,
+	0x0f, 0x73, 0x65, 0x70, 0x61, 0x72, 0x61, 0x74, 0x65, 0x5f, 0x73, 0x74, 0x64, 0x65, 0x72, 0x72,
+	0x18, 0x0b, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0e, 0x73, 0x65, 0x70, 0x61, 0x72, 0x61, 0x74, 0x65,
+	0x53, 0x74, 0x64, 0x65, 0x72, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69,
+	0x74, 0x79, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x72, 0x69, 0x6f, 0x72, 0x69,
+	0x74, 0x79, 0x1a, 0x2d, 0x0a, 0x03, 0x45, 0x6e, 0x76, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x1a, 0xac, 0x01, 0x0a, 0x0c, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x42, 0x75, 0x66, 0x66,
+	0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x4f, 0x0a, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x37, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x48,
+	0x65, 0x61, 0x64, 0x2e, 0x4f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x42, 0x75, 0x66, 0x66, 0x65, 0x72,
+	0x2e, 0x4f, 0x76, 0x65, 0x72, 0x66, 0x6c, 0x6f, 0x77, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52,
+	0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x22, 0x37, 0x0a, 0x0e, 0x4f, 0x76, 0x65, 0x72, 0x66,
+	0x6c, 0x6f, 0x77, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x09, 0x0a, 0x05, 0x42, 0x4c, 0x4f,
+	0x43, 0x4b, 0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x44, 0x52, 0x4f, 0x50, 0x5f, 0x4f, 0x4c, 0x44,
+	0x45, 0x53, 0x54, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x53, 0x50, 0x49, 0x4c, 0x4c, 0x10, 0x02,
+	0x1a, 0x07, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x72, 0x74, 0x1a, 0x2f, 0x0a, 0x05, 0x53, 0x74, 0x64,
+	0x69, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x64, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x05, 0x73, 0x74, 0x64, 0x69, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x6f, 0x66, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x65, 0x6f, 0x66, 0x1a, 0xeb, 0x01, 0x0a, 0x07, 0x41,
+	0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f,
+	0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x63, 0x6f, 0x6e,
+	0x74, 0x65, 0x6e, 0x74, 0x12, 0x4c, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x2a, 0x2e, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x48, 0x00, 0x52, 0x04, 0x68, 0x65, 0x61, 0x64, 0x12, 0x34,
-	0x0a, 0x05, 0x73, 0x74, 0x64, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e,
+	0x74, 0x2e, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x72, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x6f, 0x66, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x03, 0x65, 0x6f, 0x66, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x65,
+	0x5f, 0x6f, 0x77, 0x6e, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0d, 0x70, 0x72,
+	0x65, 0x73, 0x65, 0x72, 0x76, 0x65, 0x4f, 0x77, 0x6e, 0x65, 0x72, 0x22, 0x2b, 0x0a, 0x0b, 0x43,
+	0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x08, 0x0a, 0x04, 0x4e, 0x4f,
+	0x4e, 0x45, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x47, 0x5a, 0x49, 0x50, 0x10, 0x01, 0x12, 0x08,
+	0x0a, 0x04, 0x5a, 0x53, 0x54, 0x44, 0x10, 0x02, 0x1a, 0x94, 0x01, 0x0a, 0x0a, 0x43, 0x61, 0x63,
+	0x68, 0x65, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36, 0x12, 0x1e, 0x0a, 0x0a, 0x65,
+	0x78, 0x65, 0x63, 0x75, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x0a, 0x65, 0x78, 0x65, 0x63, 0x75, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x32, 0x0a, 0x08, 0x6d,
+	0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x4d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x42,
+	0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x2d, 0x0a, 0x03, 0x50, 0x49,
+	0x44, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69,
+	0x64, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x06, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x6c, 0x22, 0x9b, 0x06, 0x0a, 0x0d, 0x53, 0x70,
+	0x61, 0x77, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x38, 0x0a, 0x06, 0x73,
+	0x74, 0x64, 0x6f, 0x75, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x53, 0x74, 0x64, 0x6f, 0x75, 0x74, 0x48, 0x00, 0x52, 0x06, 0x73,
+	0x74, 0x64, 0x6f, 0x75, 0x74, 0x12, 0x38, 0x0a, 0x06, 0x73, 0x74, 0x64, 0x65, 0x72, 0x72, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x53,
+	0x74, 0x64, 0x65, 0x72, 0x72, 0x48, 0x00, 0x52, 0x06, 0x73, 0x74, 0x64, 0x65, 0x72, 0x72, 0x12,
+	0x32, 0x0a, 0x04, 0x65, 0x78, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e,
 	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x53, 0x74, 0x64, 0x69, 0x6e, 0x48, 0x00, 0x52, 0x05, 0x73,
-	0x74, 0x64, 0x69, 0x6e, 0x12, 0x34, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x04, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53,
-	0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x53, 0x74, 0x61, 0x72,
-	0x74, 0x48, 0x00, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x1a, 0x78, 0x0a, 0x04, 0x46, 0x69,
-	0x6c, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18,
-	0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52,
-	0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x65, 0x78, 0x65, 0x63,
-	0x75, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x65, 0x78,
-	0x65, 0x63, 0x75, 0x74, 0x61, 0x62, 0x6c, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x74, 0x72, 0x75, 0x6e,
-	0x63, 0x61, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x74, 0x72, 0x75, 0x6e,
-	0x63, 0x61, 0x74, 0x65, 0x1a, 0xa3, 0x02, 0x0a, 0x04, 0x48, 0x65, 0x61, 0x64, 0x12, 0x18, 0x0a,
-	0x07, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
-	0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x73, 0x18,
-	0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x12, 0x33, 0x0a, 0x04, 0x65,
-	0x6e, 0x76, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x2e, 0x48, 0x65, 0x61, 0x64, 0x2e, 0x45, 0x6e, 0x76, 0x52, 0x04, 0x65, 0x6e, 0x76, 0x73,
-	0x12, 0x1b, 0x0a, 0x09, 0x68, 0x61, 0x73, 0x5f, 0x73, 0x74, 0x64, 0x69, 0x6e, 0x18, 0x04, 0x20,
-	0x01, 0x28, 0x08, 0x52, 0x08, 0x68, 0x61, 0x73, 0x53, 0x74, 0x64, 0x69, 0x6e, 0x12, 0x12, 0x0a,
-	0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74,
-	0x68, 0x12, 0x21, 0x0a, 0x0c, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x70, 0x74,
-	0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74,
-	0x65, 0x50, 0x74, 0x79, 0x12, 0x35, 0x0a, 0x0b, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x5f, 0x73,
-	0x69, 0x7a, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x53, 0x69, 0x7a, 0x65, 0x52,
-	0x0a, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x53, 0x69, 0x7a, 0x65, 0x1a, 0x2d, 0x0a, 0x03, 0x45,
-	0x6e, 0x76, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x1a, 0x07, 0x0a, 0x05, 0x53, 0x74,
-	0x61, 0x72, 0x74, 0x1a, 0x2f, 0x0a, 0x05, 0x53, 0x74, 0x64, 0x69, 0x6e, 0x12, 0x14, 0x0a, 0x05,
-	0x73, 0x74, 0x64, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x05, 0x73, 0x74, 0x64,
-	0x69, 0x6e, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x6f, 0x66, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
-	0x03, 0x65, 0x6f, 0x66, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22,
-	0x15, 0x0a, 0x03, 0x50, 0x49, 0x44, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x22, 0xa7, 0x03, 0x0a, 0x0d, 0x53, 0x70, 0x61, 0x77, 0x6e,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x38, 0x0a, 0x06, 0x73, 0x74, 0x64, 0x6f,
-	0x75, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x2e, 0x53, 0x74, 0x64, 0x6f, 0x75, 0x74, 0x48, 0x00, 0x52, 0x06, 0x73, 0x74, 0x64, 0x6f,
-	0x75, 0x74, 0x12, 0x38, 0x0a, 0x06, 0x73, 0x74, 0x64, 0x65, 0x72, 0x72, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70,
-	0x61, 0x77, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x53, 0x74, 0x64, 0x65,
-	0x72, 0x72, 0x48, 0x00, 0x52, 0x06, 0x73, 0x74, 0x64, 0x65, 0x72, 0x72, 0x12, 0x32, 0x0a, 0x04,
-	0x65, 0x78, 0x69, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x70, 0x72, 0x6f,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x45, 0x78, 0x69, 0x74, 0x48, 0x00, 0x52, 0x04, 0x65,
+	0x78, 0x69, 0x74, 0x12, 0x21, 0x0a, 0x03, 0x70, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x49, 0x44, 0x48,
+	0x00, 0x52, 0x03, 0x70, 0x69, 0x64, 0x12, 0x3b, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x53,
+	0x79, 0x73, 0x74, 0x65, 0x6d, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x12, 0x2f, 0x0a, 0x03, 0x67, 0x61, 0x70, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70, 0x61, 0x77,
+	0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x47, 0x61, 0x70, 0x48, 0x00, 0x52,
+	0x03, 0x67, 0x61, 0x70, 0x12, 0x38, 0x0a, 0x06, 0x71, 0x75, 0x65, 0x75, 0x65, 0x64, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e,
+	0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x51, 0x75,
+	0x65, 0x75, 0x65, 0x64, 0x48, 0x00, 0x52, 0x06, 0x71, 0x75, 0x65, 0x75, 0x65, 0x64, 0x1a, 0x5f,
+	0x0a, 0x06, 0x53, 0x74, 0x64, 0x6f, 0x75, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x64, 0x6f,
+	0x75, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x74, 0x64, 0x6f, 0x75, 0x74,
+	0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x21, 0x0a, 0x0c,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x5f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x0b, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x4e, 0x73, 0x1a,
+	0x5f, 0x0a, 0x06, 0x53, 0x74, 0x64, 0x65, 0x72, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74, 0x64,
+	0x65, 0x72, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x74, 0x64, 0x65, 0x72,
+	0x72, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x65, 0x12, 0x21, 0x0a,
+	0x0c, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x5f, 0x6e, 0x73, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0b, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x4e, 0x73,
+	0x1a, 0x32, 0x0a, 0x04, 0x45, 0x78, 0x69, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x73, 0x69, 0x67, 0x6e, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x73, 0x69,
+	0x67, 0x6e, 0x61, 0x6c, 0x1a, 0x23, 0x0a, 0x0b, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x45, 0x72,
+	0x72, 0x6f, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x1a, 0x4b, 0x0a, 0x03, 0x47, 0x61, 0x70,
+	0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74, 0x64, 0x6f, 0x75, 0x74, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x73, 0x74, 0x64, 0x6f, 0x75, 0x74, 0x42, 0x79,
+	0x74, 0x65, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74, 0x64, 0x65, 0x72, 0x72, 0x5f, 0x62, 0x79,
+	0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x73, 0x74, 0x64, 0x65, 0x72,
+	0x72, 0x42, 0x79, 0x74, 0x65, 0x73, 0x1a, 0x24, 0x0a, 0x06, 0x51, 0x75, 0x65, 0x75, 0x65, 0x64,
+	0x12, 0x1a, 0x0a, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x09, 0x0a, 0x07,
+	0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x24, 0x0a, 0x0c, 0x4b, 0x69, 0x6c, 0x6c, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x31, 0x0a,
+	0x17, 0x46, 0x69, 0x6e, 0x64, 0x4d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x42, 0x6c, 0x6f, 0x62,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x68, 0x61, 0x32,
+	0x35, 0x36, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36,
+	0x22, 0x4a, 0x0a, 0x18, 0x46, 0x69, 0x6e, 0x64, 0x4d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x42,
+	0x6c, 0x6f, 0x62, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18, 0x0a, 0x07,
+	0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07, 0x6d,
+	0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x42, 0x0a, 0x0e,
+	0x50, 0x75, 0x74, 0x42, 0x6c, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x73, 0x68, 0x61, 0x32, 0x35, 0x36, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x73, 0x68, 0x61, 0x32, 0x35, 0x36, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x63, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74,
+	0x22, 0x27, 0x0a, 0x0f, 0x50, 0x75, 0x74, 0x42, 0x6c, 0x6f, 0x62, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x22, 0x0a, 0x0c, 0x44, 0x72, 0x61,
+	0x69, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x77, 0x61, 0x69,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x77, 0x61, 0x69, 0x74, 0x22, 0x3f, 0x0a,
+	0x0d, 0x44, 0x72, 0x61, 0x69, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18,
+	0x0a, 0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52,
+	0x07, 0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x0d,
+	0x0a, 0x0b, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xed, 0x04,
+	0x0a, 0x0c, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x18,
+	0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x6f, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x6f, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x63, 0x68,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x61, 0x72, 0x63, 0x68, 0x12, 0x1a, 0x0a, 0x08,
+	0x68, 0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x68, 0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x68, 0x65, 0x6c,
+	0x6c, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x73, 0x68, 0x65, 0x6c, 0x6c, 0x73,
+	0x12, 0x2d, 0x0a, 0x08, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18, 0x06, 0x20, 0x03,
+	0x28, 0x0e, 0x32, 0x11, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x46, 0x65,
+	0x61, 0x74, 0x75, 0x72, 0x65, 0x52, 0x08, 0x66, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x12,
+	0x20, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x6f, 0x72, 0x73, 0x18, 0x07,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x6f, 0x72,
+	0x73, 0x12, 0x35, 0x0a, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x49, 0x6e, 0x66,
+	0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73,
+	0x52, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x12, 0x2f, 0x0a, 0x04, 0x6c, 0x6f, 0x61, 0x64,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x2e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x4c,
+	0x6f, 0x61, 0x64, 0x52, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x1a, 0xb8, 0x01, 0x0a, 0x06, 0x4c, 0x69,
+	0x6d, 0x69, 0x74, 0x73, 0x12, 0x1f, 0x0a, 0x0b, 0x6d, 0x61, 0x78, 0x5f, 0x72, 0x75, 0x6e, 0x6e,
+	0x69, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0a, 0x6d, 0x61, 0x78, 0x52, 0x75,
+	0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x33, 0x0a, 0x16, 0x6d, 0x61, 0x78, 0x5f, 0x72, 0x75, 0x6e,
+	0x6e, 0x69, 0x6e, 0x67, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x63, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x13, 0x6d, 0x61, 0x78, 0x52, 0x75, 0x6e, 0x6e, 0x69, 0x6e,
+	0x67, 0x50, 0x65, 0x72, 0x43, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x12, 0x2c, 0x0a, 0x12, 0x6f, 0x75,
+	0x74, 0x70, 0x75, 0x74, 0x5f, 0x62, 0x75, 0x66, 0x66, 0x65, 0x72, 0x5f, 0x73, 0x69, 0x7a, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x10, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x42, 0x75,
+	0x66, 0x66, 0x65, 0x72, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x2a, 0x0a, 0x11, 0x6f, 0x75, 0x74, 0x70,
+	0x75, 0x74, 0x5f, 0x62, 0x75, 0x66, 0x66, 0x65, 0x72, 0x5f, 0x6d, 0x61, 0x78, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0f, 0x6f, 0x75, 0x74, 0x70, 0x75, 0x74, 0x42, 0x75, 0x66, 0x66, 0x65,
+	0x72, 0x4d, 0x61, 0x78, 0x1a, 0x77, 0x0a, 0x04, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x18, 0x0a, 0x07,
+	0x72, 0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x72,
+	0x75, 0x6e, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x16, 0x0a, 0x06, 0x71, 0x75, 0x65, 0x75, 0x65, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x06, 0x71, 0x75, 0x65, 0x75, 0x65, 0x64, 0x12, 0x1a,
+	0x0a, 0x08, 0x64, 0x72, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x08, 0x64, 0x72, 0x61, 0x69, 0x6e, 0x69, 0x6e, 0x67, 0x12, 0x21, 0x0a, 0x0c, 0x6c, 0x6f,
+	0x61, 0x64, 0x5f, 0x61, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x0b, 0x6c, 0x6f, 0x61, 0x64, 0x41, 0x76, 0x65, 0x72, 0x61, 0x67, 0x65, 0x2a, 0xa2, 0x02,
+	0x0a, 0x07, 0x46, 0x65, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x17, 0x0a, 0x13, 0x46, 0x45, 0x41,
+	0x54, 0x55, 0x52, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44,
+	0x10, 0x00, 0x12, 0x0f, 0x0a, 0x0b, 0x46, 0x45, 0x41, 0x54, 0x55, 0x52, 0x45, 0x5f, 0x50, 0x54,
+	0x59, 0x10, 0x01, 0x12, 0x16, 0x0a, 0x12, 0x46, 0x45, 0x41, 0x54, 0x55, 0x52, 0x45, 0x5f, 0x46,
+	0x49, 0x4c, 0x45, 0x5f, 0x43, 0x41, 0x43, 0x48, 0x45, 0x10, 0x02, 0x12, 0x13, 0x0a, 0x0f, 0x46,
+	0x45, 0x41, 0x54, 0x55, 0x52, 0x45, 0x5f, 0x41, 0x52, 0x43, 0x48, 0x49, 0x56, 0x45, 0x10, 0x03,
+	0x12, 0x19, 0x0a, 0x15, 0x46, 0x45, 0x41, 0x54, 0x55, 0x52, 0x45, 0x5f, 0x46, 0x49, 0x4c, 0x45,
+	0x5f, 0x4d, 0x45, 0x54, 0x41, 0x44, 0x41, 0x54, 0x41, 0x10, 0x04, 0x12, 0x19, 0x0a, 0x15, 0x46,
+	0x45, 0x41, 0x54, 0x55, 0x52, 0x45, 0x5f, 0x4f, 0x55, 0x54, 0x50, 0x55, 0x54, 0x5f, 0x42, 0x55,
+	0x46, 0x46, 0x45, 0x52, 0x10, 0x05, 0x12, 0x16, 0x0a, 0x12, 0x46, 0x45, 0x41, 0x54, 0x55, 0x52,
+	0x45, 0x5f, 0x54, 0x49, 0x4d, 0x45, 0x53, 0x54, 0x41, 0x4d, 0x50, 0x53, 0x10, 0x06, 0x12, 0x18,
+	0x0a, 0x14, 0x46, 0x45, 0x41, 0x54, 0x55, 0x52, 0x45, 0x5f, 0x4d, 0x45, 0x52, 0x47, 0x45, 0x5f,
+	0x53, 0x54, 0x44, 0x45, 0x52, 0x52, 0x10, 0x07, 0x12, 0x1b, 0x0a, 0x17, 0x46, 0x45, 0x41, 0x54,
+	0x55, 0x52, 0x45, 0x5f, 0x53, 0x45, 0x50, 0x41, 0x52, 0x41, 0x54, 0x45, 0x5f, 0x53, 0x54, 0x44,
+	0x45, 0x52, 0x52, 0x10, 0x08, 0x12, 0x14, 0x0a, 0x10, 0x46, 0x45, 0x41, 0x54, 0x55, 0x52, 0x45,
+	0x5f, 0x50, 0x52, 0x49, 0x4f, 0x52, 0x49, 0x54, 0x59, 0x10, 0x09, 0x12, 0x11, 0x0a, 0x0d, 0x46,
+	0x45, 0x41, 0x54, 0x55, 0x52, 0x45, 0x5f, 0x44, 0x52, 0x41, 0x49, 0x4e, 0x10, 0x0a, 0x12, 0x12,
+	0x0a, 0x0e, 0x46, 0x45, 0x41, 0x54, 0x55, 0x52, 0x45, 0x5f, 0x53, 0x49, 0x47, 0x4e, 0x41, 0x4c,
+	0x10, 0x0b, 0x32, 0x9b, 0x03, 0x0a, 0x12, 0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x43, 0x6f, 0x64,
+	0x65, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x6f, 0x72, 0x12, 0x3e, 0x0a, 0x05, 0x53, 0x70, 0x61,
+	0x77, 0x6e, 0x12, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70,
+	0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x70, 0x72, 0x6f,
 	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x2e, 0x45, 0x78, 0x69, 0x74, 0x48, 0x00, 0x52, 0x04, 0x65, 0x78, 0x69, 0x74,
-	0x12, 0x21, 0x0a, 0x03, 0x70, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0d, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x49, 0x44, 0x48, 0x00, 0x52, 0x03,
-	0x70, 0x69, 0x64, 0x12, 0x3b, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x23, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70,
-	0x61, 0x77, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x53, 0x79, 0x73, 0x74,
-	0x65, 0x6d, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
-	0x1a, 0x20, 0x0a, 0x06, 0x53, 0x74, 0x64, 0x6f, 0x75, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x74,
-	0x64, 0x6f, 0x75, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x74, 0x64, 0x6f,
-	0x75, 0x74, 0x1a, 0x20, 0x0a, 0x06, 0x53, 0x74, 0x64, 0x65, 0x72, 0x72, 0x12, 0x16, 0x0a, 0x06,
-	0x73, 0x74, 0x64, 0x65, 0x72, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x74,
-	0x64, 0x65, 0x72, 0x72, 0x1a, 0x1a, 0x0a, 0x04, 0x45, 0x78, 0x69, 0x74, 0x12, 0x12, 0x0a, 0x04,
-	0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65,
-	0x1a, 0x23, 0x0a, 0x0b, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12,
-	0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
-	0x65, 0x72, 0x72, 0x6f, 0x72, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
-	0x22, 0x24, 0x0a, 0x0c, 0x4b, 0x69, 0x6c, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
-	0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x32, 0x85, 0x01, 0x0a, 0x12, 0x52, 0x65, 0x6d, 0x6f, 0x74,
-	0x65, 0x43, 0x6f, 0x64, 0x65, 0x45, 0x78, 0x65, 0x63, 0x75, 0x74, 0x6f, 0x72, 0x12, 0x3e, 0x0a,
-	0x05, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x12, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
-	0x6c, 0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x70, 0x61, 0x77, 0x6e, 0x52,
-	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x2f, 0x0a,
-	0x04, 0x4b, 0x69, 0x6c, 0x6c, 0x12, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
-	0x2e, 0x50, 0x49, 0x44, 0x1a, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e,
-	0x4b, 0x69, 0x6c, 0x6c, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x42, 0x21,
-	0x5a, 0x1f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x72, 0x65, 0x79,
-	0x6f, 0x75, 0x6e, 0x67, 0x2f, 0x72, 0x63, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
-	0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6e, 0x73, 0x65, 0x22, 0x00, 0x28, 0x01, 0x30, 0x01, 0x12, 0x2f, 0x0a, 0x04, 0x4b, 0x69, 0x6c,
+	0x6c, 0x12, 0x0d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x49, 0x44,
+	0x1a, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x4b, 0x69, 0x6c, 0x6c,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x5b, 0x0a, 0x10, 0x46, 0x69,
+	0x6e, 0x64, 0x4d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x42, 0x6c, 0x6f, 0x62, 0x73, 0x12, 0x21,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x46, 0x69, 0x6e, 0x64, 0x4d, 0x69,
+	0x73, 0x73, 0x69, 0x6e, 0x67, 0x42, 0x6c, 0x6f, 0x62, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x22, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x46, 0x69, 0x6e,
+	0x64, 0x4d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x42, 0x6c, 0x6f, 0x62, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x42, 0x0a, 0x07, 0x50, 0x75, 0x74, 0x42, 0x6c,
+	0x6f, 0x62, 0x12, 0x18, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x75,
+	0x74, 0x42, 0x6c, 0x6f, 0x62, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x75, 0x74, 0x42, 0x6c, 0x6f, 0x62, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x28, 0x01, 0x12, 0x3a, 0x0a, 0x05, 0x44,
+	0x72, 0x61, 0x69, 0x6e, 0x12, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e,
+	0x44, 0x72, 0x61, 0x69, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x44, 0x72, 0x61, 0x69, 0x6e, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x37, 0x0a, 0x04, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x49, 0x6e, 0x66, 0x6f, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x2e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00,
+	0x42, 0x21, 0x5a, 0x1f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x72,
+	0x65, 0x79, 0x6f, 0x75, 0x6e, 0x67, 0x2f, 0x72, 0x63, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1011,44 +2632,86 @@ func file_rce_proto_rawDescGZIP() []byte {
 	return file_rce_proto_rawDescData
 }
 
-var file_rce_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_rce_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_rce_proto_msgTypes = make([]protoimpl.MessageInfo, 31)
 var file_rce_proto_goTypes = []interface{}{
-	(*WindowSize)(nil),                // 0: protocol.WindowSize
-	(*SpawnRequest)(nil),              // 1: protocol.SpawnRequest
-	(*PID)(nil),                       // 2: protocol.PID
-	(*SpawnResponse)(nil),             // 3: protocol.SpawnResponse
-	(*KillResponse)(nil),              // 4: protocol.KillResponse
-	(*SpawnRequest_File)(nil),         // 5: protocol.SpawnRequest.File
-	(*SpawnRequest_Head)(nil),         // 6: protocol.SpawnRequest.Head
-	(*SpawnRequest_Start)(nil),        // 7: protocol.SpawnRequest.Start
-	(*SpawnRequest_Stdin)(nil),        // 8: protocol.SpawnRequest.Stdin
-	(*SpawnRequest_Head_Env)(nil),     // 9: protocol.SpawnRequest.Head.Env
-	(*SpawnResponse_Stdout)(nil),      // 10: protocol.SpawnResponse.Stdout
-	(*SpawnResponse_Stderr)(nil),      // 11: protocol.SpawnResponse.Stderr
-	(*SpawnResponse_Exit)(nil),        // 12: protocol.SpawnResponse.Exit
-	(*SpawnResponse_SystemError)(nil), // 13: protocol.SpawnResponse.SystemError
+	(Feature)(0), // 0: protocol.Feature
+	(SpawnRequest_Head_OutputBuffer_OverflowPolicy)(0), // 1: protocol.SpawnRequest.Head.OutputBuffer.OverflowPolicy
+	(SpawnRequest_Archive_Compression)(0),              // 2: protocol.SpawnRequest.Archive.Compression
+	(*WindowSize)(nil),                                 // 3: protocol.WindowSize
+	(*FileMetadata)(nil),                               // 4: protocol.FileMetadata
+	(*SpawnRequest)(nil),                               // 5: protocol.SpawnRequest
+	(*PID)(nil),                                        // 6: protocol.PID
+	(*SpawnResponse)(nil),                              // 7: protocol.SpawnResponse
+	(*KillResponse)(nil),                               // 8: protocol.KillResponse
+	(*FindMissingBlobsRequest)(nil),                    // 9: protocol.FindMissingBlobsRequest
+	(*FindMissingBlobsResponse)(nil),                   // 10: protocol.FindMissingBlobsResponse
+	(*PutBlobRequest)(nil),                             // 11: protocol.PutBlobRequest
+	(*PutBlobResponse)(nil),                            // 12: protocol.PutBlobResponse
+	(*DrainRequest)(nil),                               // 13: protocol.DrainRequest
+	(*DrainResponse)(nil),                              // 14: protocol.DrainResponse
+	(*InfoRequest)(nil),                                // 15: protocol.InfoRequest
+	(*InfoResponse)(nil),                               // 16: protocol.InfoResponse
+	(*FileMetadata_Owner)(nil),                         // 17: protocol.FileMetadata.Owner
+	(*SpawnRequest_File)(nil),                          // 18: protocol.SpawnRequest.File
+	(*SpawnRequest_Head)(nil),                          // 19: protocol.SpawnRequest.Head
+	(*SpawnRequest_Start)(nil),                         // 20: protocol.SpawnRequest.Start
+	(*SpawnRequest_Stdin)(nil),                         // 21: protocol.SpawnRequest.Stdin
+	(*SpawnRequest_Archive)(nil),                       // 22: protocol.SpawnRequest.Archive
+	(*SpawnRequest_CachedFile)(nil),                    // 23: protocol.SpawnRequest.CachedFile
+	(*SpawnRequest_Head_Env)(nil),                      // 24: protocol.SpawnRequest.Head.Env
+	(*SpawnRequest_Head_OutputBuffer)(nil),             // 25: protocol.SpawnRequest.Head.OutputBuffer
+	(*SpawnResponse_Stdout)(nil),                       // 26: protocol.SpawnResponse.Stdout
+	(*SpawnResponse_Stderr)(nil),                       // 27: protocol.SpawnResponse.Stderr
+	(*SpawnResponse_Exit)(nil),                         // 28: protocol.SpawnResponse.Exit
+	(*SpawnResponse_SystemError)(nil),                  // 29: protocol.SpawnResponse.SystemError
+	(*SpawnResponse_Gap)(nil),                          // 30: protocol.SpawnResponse.Gap
+	(*SpawnResponse_Queued)(nil),                       // 31: protocol.SpawnResponse.Queued
+	(*InfoResponse_Limits)(nil),                        // 32: protocol.InfoResponse.Limits
+	(*InfoResponse_Load)(nil),                          // 33: protocol.InfoResponse.Load
 }
 var file_rce_proto_depIdxs = []int32{
-	5,  // 0: protocol.SpawnRequest.file:type_name -> protocol.SpawnRequest.File
-	6,  // 1: protocol.SpawnRequest.head:type_name -> protocol.SpawnRequest.Head
-	8,  // 2: protocol.SpawnRequest.stdin:type_name -> protocol.SpawnRequest.Stdin
-	7,  // 3: protocol.SpawnRequest.start:type_name -> protocol.SpawnRequest.Start
-	10, // 4: protocol.SpawnResponse.stdout:type_name -> protocol.SpawnResponse.Stdout
-	11, // 5: protocol.SpawnResponse.stderr:type_name -> protocol.SpawnResponse.Stderr
-	12, // 6: protocol.SpawnResponse.exit:type_name -> protocol.SpawnResponse.Exit
-	2,  // 7: protocol.SpawnResponse.pid:type_name -> protocol.PID
-	13, // 8: protocol.SpawnResponse.error:type_name -> protocol.SpawnResponse.SystemError
-	9,  // 9: protocol.SpawnRequest.Head.envs:type_name -> protocol.SpawnRequest.Head.Env
-	0,  // 10: protocol.SpawnRequest.Head.window_size:type_name -> protocol.WindowSize
-	1,  // 11: protocol.RemoteCodeExecutor.Spawn:input_type -> protocol.SpawnRequest
-	2,  // 12: protocol.RemoteCodeExecutor.Kill:input_type -> protocol.PID
-	3,  // 13: protocol.RemoteCodeExecutor.Spawn:output_type -> protocol.SpawnResponse
-	4,  // 14: protocol.RemoteCodeExecutor.Kill:output_type -> protocol.KillResponse
-	13, // [13:15] is the sub-list for method output_type
-	11, // [11:13] is the sub-list for method input_type
-	11, // [11:11] is the sub-list for extension type_name
-	11, // [11:11] is the sub-list for extension extendee
-	0,  // [0:11] is the sub-list for field type_name
+	17, // 0: protocol.FileMetadata.owner:type_name -> protocol.FileMetadata.Owner
+	18, // 1: protocol.SpawnRequest.file:type_name -> protocol.SpawnRequest.File
+	19, // 2: protocol.SpawnRequest.head:type_name -> protocol.SpawnRequest.Head
+	21, // 3: protocol.SpawnRequest.stdin:type_name -> protocol.SpawnRequest.Stdin
+	20, // 4: protocol.SpawnRequest.start:type_name -> protocol.SpawnRequest.Start
+	22, // 5: protocol.SpawnRequest.archive:type_name -> protocol.SpawnRequest.Archive
+	23, // 6: protocol.SpawnRequest.cached_file:type_name -> protocol.SpawnRequest.CachedFile
+	26, // 7: protocol.SpawnResponse.stdout:type_name -> protocol.SpawnResponse.Stdout
+	27, // 8: protocol.SpawnResponse.stderr:type_name -> protocol.SpawnResponse.Stderr
+	28, // 9: protocol.SpawnResponse.exit:type_name -> protocol.SpawnResponse.Exit
+	6,  // 10: protocol.SpawnResponse.pid:type_name -> protocol.PID
+	29, // 11: protocol.SpawnResponse.error:type_name -> protocol.SpawnResponse.SystemError
+	30, // 12: protocol.SpawnResponse.gap:type_name -> protocol.SpawnResponse.Gap
+	31, // 13: protocol.SpawnResponse.queued:type_name -> protocol.SpawnResponse.Queued
+	0,  // 14: protocol.InfoResponse.features:type_name -> protocol.Feature
+	32, // 15: protocol.InfoResponse.limits:type_name -> protocol.InfoResponse.Limits
+	33, // 16: protocol.InfoResponse.load:type_name -> protocol.InfoResponse.Load
+	4,  // 17: protocol.SpawnRequest.File.metadata:type_name -> protocol.FileMetadata
+	24, // 18: protocol.SpawnRequest.Head.envs:type_name -> protocol.SpawnRequest.Head.Env
+	3,  // 19: protocol.SpawnRequest.Head.window_size:type_name -> protocol.WindowSize
+	25, // 20: protocol.SpawnRequest.Head.output_buffer:type_name -> protocol.SpawnRequest.Head.OutputBuffer
+	2,  // 21: protocol.SpawnRequest.Archive.compression:type_name -> protocol.SpawnRequest.Archive.Compression
+	4,  // 22: protocol.SpawnRequest.CachedFile.metadata:type_name -> protocol.FileMetadata
+	1,  // 23: protocol.SpawnRequest.Head.OutputBuffer.policy:type_name -> protocol.SpawnRequest.Head.OutputBuffer.OverflowPolicy
+	5,  // 24: protocol.RemoteCodeExecutor.Spawn:input_type -> protocol.SpawnRequest
+	6,  // 25: protocol.RemoteCodeExecutor.Kill:input_type -> protocol.PID
+	9,  // 26: protocol.RemoteCodeExecutor.FindMissingBlobs:input_type -> protocol.FindMissingBlobsRequest
+	11, // 27: protocol.RemoteCodeExecutor.PutBlob:input_type -> protocol.PutBlobRequest
+	13, // 28: protocol.RemoteCodeExecutor.Drain:input_type -> protocol.DrainRequest
+	15, // 29: protocol.RemoteCodeExecutor.Info:input_type -> protocol.InfoRequest
+	7,  // 30: protocol.RemoteCodeExecutor.Spawn:output_type -> protocol.SpawnResponse
+	8,  // 31: protocol.RemoteCodeExecutor.Kill:output_type -> protocol.KillResponse
+	10, // 32: protocol.RemoteCodeExecutor.FindMissingBlobs:output_type -> protocol.FindMissingBlobsResponse
+	12, // 33: protocol.RemoteCodeExecutor.PutBlob:output_type -> protocol.PutBlobResponse
+	14, // 34: protocol.RemoteCodeExecutor.Drain:output_type -> protocol.DrainResponse
+	16, // 35: protocol.RemoteCodeExecutor.Info:output_type -> protocol.InfoResponse
+	30, // [30:36] is the sub-list for method output_type
+	24, // [24:30] is the sub-list for method input_type
+	24, // [24:24] is the sub-list for extension type_name
+	24, // [24:24] is the sub-list for extension extendee
+	0,  // [0:24] is the sub-list for field type_name
 }
 
 func init() { file_rce_proto_init() }
@@ -1070,7 +2733,7 @@ func file_rce_proto_init() {
 			}
 		}
 		file_rce_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SpawnRequest); i {
+			switch v := v.(*FileMetadata); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1082,7 +2745,7 @@ func file_rce_proto_init() {
 			}
 		}
 		file_rce_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*PID); i {
+			switch v := v.(*SpawnRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1094,7 +2757,7 @@ func file_rce_proto_init() {
 			}
 		}
 		file_rce_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SpawnResponse); i {
+			switch v := v.(*PID); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1106,7 +2769,7 @@ func file_rce_proto_init() {
 			}
 		}
 		file_rce_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*KillResponse); i {
+			switch v := v.(*SpawnResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1118,7 +2781,7 @@ func file_rce_proto_init() {
 			}
 		}
 		file_rce_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SpawnRequest_File); i {
+			switch v := v.(*KillResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1130,7 +2793,7 @@ func file_rce_proto_init() {
 			}
 		}
 		file_rce_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SpawnRequest_Head); i {
+			switch v := v.(*FindMissingBlobsRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1142,7 +2805,7 @@ func file_rce_proto_init() {
 			}
 		}
 		file_rce_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SpawnRequest_Start); i {
+			switch v := v.(*FindMissingBlobsResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1154,7 +2817,7 @@ func file_rce_proto_init() {
 			}
 		}
 		file_rce_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SpawnRequest_Stdin); i {
+			switch v := v.(*PutBlobRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1166,7 +2829,7 @@ func file_rce_proto_init() {
 			}
 		}
 		file_rce_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SpawnRequest_Head_Env); i {
+			switch v := v.(*PutBlobResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1178,7 +2841,7 @@ func file_rce_proto_init() {
 			}
 		}
 		file_rce_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SpawnResponse_Stdout); i {
+			switch v := v.(*DrainRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1190,7 +2853,7 @@ func file_rce_proto_init() {
 			}
 		}
 		file_rce_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SpawnResponse_Stderr); i {
+			switch v := v.(*DrainResponse); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1202,7 +2865,7 @@ func file_rce_proto_init() {
 			}
 		}
 		file_rce_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*SpawnResponse_Exit); i {
+			switch v := v.(*InfoRequest); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1214,6 +2877,162 @@ func file_rce_proto_init() {
 			}
 		}
 		file_rce_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*InfoResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FileMetadata_Owner); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnRequest_File); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnRequest_Head); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnRequest_Start); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnRequest_Stdin); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnRequest_Archive); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnRequest_CachedFile); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnRequest_Head_Env); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnRequest_Head_OutputBuffer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnResponse_Stdout); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnResponse_Stderr); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnResponse_Exit); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*SpawnResponse_SystemError); i {
 			case 0:
 				return &v.state
@@ -1225,32 +3044,85 @@ func file_rce_proto_init() {
 				return nil
 			}
 		}
+		file_rce_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnResponse_Gap); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpawnResponse_Queued); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*InfoResponse_Limits); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rce_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*InfoResponse_Load); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
-	file_rce_proto_msgTypes[1].OneofWrappers = []interface{}{
+	file_rce_proto_msgTypes[2].OneofWrappers = []interface{}{
 		(*SpawnRequest_File_)(nil),
 		(*SpawnRequest_Head_)(nil),
 		(*SpawnRequest_Stdin_)(nil),
 		(*SpawnRequest_Start_)(nil),
+		(*SpawnRequest_Archive_)(nil),
+		(*SpawnRequest_CachedFile_)(nil),
 	}
-	file_rce_proto_msgTypes[3].OneofWrappers = []interface{}{
+	file_rce_proto_msgTypes[4].OneofWrappers = []interface{}{
 		(*SpawnResponse_Stdout_)(nil),
 		(*SpawnResponse_Stderr_)(nil),
 		(*SpawnResponse_Exit_)(nil),
 		(*SpawnResponse_Pid)(nil),
 		(*SpawnResponse_Error)(nil),
+		(*SpawnResponse_Gap_)(nil),
+		(*SpawnResponse_Queued_)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_rce_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   14,
+			NumEnums:      3,
+			NumMessages:   31,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_rce_proto_goTypes,
 		DependencyIndexes: file_rce_proto_depIdxs,
+		EnumInfos:         file_rce_proto_enumTypes,
 		MessageInfos:      file_rce_proto_msgTypes,
 	}.Build()
 	File_rce_proto = out.File
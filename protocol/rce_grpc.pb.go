@@ -24,6 +24,10 @@ const _ = grpc.SupportPackageIsVersion7
 type RemoteCodeExecutorClient interface {
 	Spawn(ctx context.Context, opts ...grpc.CallOption) (RemoteCodeExecutor_SpawnClient, error)
 	Kill(ctx context.Context, in *PID, opts ...grpc.CallOption) (*KillResponse, error)
+	FindMissingBlobs(ctx context.Context, in *FindMissingBlobsRequest, opts ...grpc.CallOption) (*FindMissingBlobsResponse, error)
+	PutBlob(ctx context.Context, opts ...grpc.CallOption) (RemoteCodeExecutor_PutBlobClient, error)
+	Drain(ctx context.Context, in *DrainRequest, opts ...grpc.CallOption) (*DrainResponse, error)
+	Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error)
 }
 
 type remoteCodeExecutorClient struct {
@@ -74,12 +78,77 @@ func (c *remoteCodeExecutorClient) Kill(ctx context.Context, in *PID, opts ...gr
 	return out, nil
 }
 
+func (c *remoteCodeExecutorClient) FindMissingBlobs(ctx context.Context, in *FindMissingBlobsRequest, opts ...grpc.CallOption) (*FindMissingBlobsResponse, error) {
+	out := new(FindMissingBlobsResponse)
+	err := c.cc.Invoke(ctx, "/protocol.RemoteCodeExecutor/FindMissingBlobs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *remoteCodeExecutorClient) PutBlob(ctx context.Context, opts ...grpc.CallOption) (RemoteCodeExecutor_PutBlobClient, error) {
+	stream, err := c.cc.NewStream(ctx, &RemoteCodeExecutor_ServiceDesc.Streams[1], "/protocol.RemoteCodeExecutor/PutBlob", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &remoteCodeExecutorPutBlobClient{stream}
+	return x, nil
+}
+
+type RemoteCodeExecutor_PutBlobClient interface {
+	Send(*PutBlobRequest) error
+	CloseAndRecv() (*PutBlobResponse, error)
+	grpc.ClientStream
+}
+
+type remoteCodeExecutorPutBlobClient struct {
+	grpc.ClientStream
+}
+
+func (x *remoteCodeExecutorPutBlobClient) Send(m *PutBlobRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *remoteCodeExecutorPutBlobClient) CloseAndRecv() (*PutBlobResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(PutBlobResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *remoteCodeExecutorClient) Drain(ctx context.Context, in *DrainRequest, opts ...grpc.CallOption) (*DrainResponse, error) {
+	out := new(DrainResponse)
+	err := c.cc.Invoke(ctx, "/protocol.RemoteCodeExecutor/Drain", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *remoteCodeExecutorClient) Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error) {
+	out := new(InfoResponse)
+	err := c.cc.Invoke(ctx, "/protocol.RemoteCodeExecutor/Info", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RemoteCodeExecutorServer is the server API for RemoteCodeExecutor service.
 // All implementations must embed UnimplementedRemoteCodeExecutorServer
 // for forward compatibility
 type RemoteCodeExecutorServer interface {
 	Spawn(RemoteCodeExecutor_SpawnServer) error
 	Kill(context.Context, *PID) (*KillResponse, error)
+	FindMissingBlobs(context.Context, *FindMissingBlobsRequest) (*FindMissingBlobsResponse, error)
+	PutBlob(RemoteCodeExecutor_PutBlobServer) error
+	Drain(context.Context, *DrainRequest) (*DrainResponse, error)
+	Info(context.Context, *InfoRequest) (*InfoResponse, error)
 	mustEmbedUnimplementedRemoteCodeExecutorServer()
 }
 
@@ -93,6 +162,18 @@ func (UnimplementedRemoteCodeExecutorServer) Spawn(RemoteCodeExecutor_SpawnServe
 func (UnimplementedRemoteCodeExecutorServer) Kill(context.Context, *PID) (*KillResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Kill not implemented")
 }
+func (UnimplementedRemoteCodeExecutorServer) FindMissingBlobs(context.Context, *FindMissingBlobsRequest) (*FindMissingBlobsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindMissingBlobs not implemented")
+}
+func (UnimplementedRemoteCodeExecutorServer) PutBlob(RemoteCodeExecutor_PutBlobServer) error {
+	return status.Errorf(codes.Unimplemented, "method PutBlob not implemented")
+}
+func (UnimplementedRemoteCodeExecutorServer) Drain(context.Context, *DrainRequest) (*DrainResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Drain not implemented")
+}
+func (UnimplementedRemoteCodeExecutorServer) Info(context.Context, *InfoRequest) (*InfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Info not implemented")
+}
 func (UnimplementedRemoteCodeExecutorServer) mustEmbedUnimplementedRemoteCodeExecutorServer() {}
 
 // UnsafeRemoteCodeExecutorServer may be embedded to opt out of forward compatibility for this service.
@@ -150,6 +231,86 @@ func _RemoteCodeExecutor_Kill_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RemoteCodeExecutor_FindMissingBlobs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindMissingBlobsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteCodeExecutorServer).FindMissingBlobs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protocol.RemoteCodeExecutor/FindMissingBlobs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteCodeExecutorServer).FindMissingBlobs(ctx, req.(*FindMissingBlobsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RemoteCodeExecutor_PutBlob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RemoteCodeExecutorServer).PutBlob(&remoteCodeExecutorPutBlobServer{stream})
+}
+
+type RemoteCodeExecutor_PutBlobServer interface {
+	SendAndClose(*PutBlobResponse) error
+	Recv() (*PutBlobRequest, error)
+	grpc.ServerStream
+}
+
+type remoteCodeExecutorPutBlobServer struct {
+	grpc.ServerStream
+}
+
+func (x *remoteCodeExecutorPutBlobServer) SendAndClose(m *PutBlobResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *remoteCodeExecutorPutBlobServer) Recv() (*PutBlobRequest, error) {
+	m := new(PutBlobRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _RemoteCodeExecutor_Drain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteCodeExecutorServer).Drain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protocol.RemoteCodeExecutor/Drain",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteCodeExecutorServer).Drain(ctx, req.(*DrainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RemoteCodeExecutor_Info_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RemoteCodeExecutorServer).Info(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protocol.RemoteCodeExecutor/Info",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RemoteCodeExecutorServer).Info(ctx, req.(*InfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // RemoteCodeExecutor_ServiceDesc is the grpc.ServiceDesc for RemoteCodeExecutor service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -161,6 +322,18 @@ var RemoteCodeExecutor_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Kill",
 			Handler:    _RemoteCodeExecutor_Kill_Handler,
 		},
+		{
+			MethodName: "FindMissingBlobs",
+			Handler:    _RemoteCodeExecutor_FindMissingBlobs_Handler,
+		},
+		{
+			MethodName: "Drain",
+			Handler:    _RemoteCodeExecutor_Drain_Handler,
+		},
+		{
+			MethodName: "Info",
+			Handler:    _RemoteCodeExecutor_Info_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -169,6 +342,11 @@ var RemoteCodeExecutor_ServiceDesc = grpc.ServiceDesc{
 			ServerStreams: true,
 			ClientStreams: true,
 		},
+		{
+			StreamName:    "PutBlob",
+			Handler:       _RemoteCodeExecutor_PutBlob_Handler,
+			ClientStreams: true,
+		},
 	},
 	Metadata: "rce.proto",
 }
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/reyoung/rce/metrics"
+	"github.com/reyoung/rce/protocol"
+)
+
+var errCacheDisabled = errors.New("file cache is not enabled")
+
+func (s *Server) FindMissingBlobs(
+	ctx context.Context, req *protocol.FindMissingBlobsRequest) (*protocol.FindMissingBlobsResponse, error) {
+	if s.BlobStore == nil {
+		return &protocol.FindMissingBlobsResponse{Missing: req.Sha256, Error: errCacheDisabled.Error()}, nil
+	}
+	missing, err := s.BlobStore.Missing(req.Sha256)
+	if err != nil {
+		return &protocol.FindMissingBlobsResponse{Error: err.Error()}, nil
+	}
+	return &protocol.FindMissingBlobsResponse{Missing: missing}, nil
+}
+
+// blobReader reads the content of a PutBlob stream.
+type blobReader struct {
+	svr protocol.RemoteCodeExecutor_PutBlobServer
+	buf []byte
+}
+
+func (r *blobReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		req, err := r.svr.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = req.Content
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	metrics.UploadedBytes.WithLabelValues("blob").Add(float64(n))
+	return n, nil
+}
+
+func (s *Server) PutBlob(svr protocol.RemoteCodeExecutor_PutBlobServer) error {
+	if s.BlobStore == nil {
+		return svr.SendAndClose(&protocol.PutBlobResponse{Error: errCacheDisabled.Error()})
+	}
+	req, err := svr.Recv()
+	if err != nil {
+		return fmt.Errorf("failed to receive blob: %w", err)
+	}
+	s.logger().Debug("Receiving blob", "sha256", req.Sha256)
+	err = s.BlobStore.Put(req.Sha256, &blobReader{svr: svr, buf: req.Content})
+	if err != nil {
+		return svr.SendAndClose(&protocol.PutBlobResponse{Error: err.Error()})
+	}
+	return svr.SendAndClose(&protocol.PutBlobResponse{})
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"fmt"
+	"github.com/reyoung/rce/cas"
+	"github.com/reyoung/rce/compression"
+	"github.com/reyoung/rce/config"
+	"github.com/reyoung/rce/process"
+	"log/slog"
+	"os/user"
+	"strconv"
+)
+
+// New creates the server of cfg. The file cache and the journal are opened,
+// and the jobs left in the journal are recovered.
+func New(cfg *config.Config, logger *slog.Logger) (*Server, error) {
+	s := &Server{
+		OutputBufferSize:    cfg.OutputBuffer.Size,
+		OutputBufferMax:     cfg.OutputBuffer.Max,
+		MaxRunning:          cfg.MaxRunning,
+		MaxRunningPerCaller: cfg.MaxRunningPerCaller,
+		Logger:              logger,
+	}
+	for _, name := range cfg.AllowedUsers {
+		uid, err := lookupUID(name)
+		if err != nil {
+			return nil, err
+		}
+		s.AllowedUIDs = append(s.AllowedUIDs, uid)
+	}
+	if len(cfg.Compression) != 0 {
+		s.Compressors = cfg.Compression
+		err := compression.Register(s.Compressors...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid compression: %w", err)
+		}
+	}
+	if cfg.Cache.Dir != "" {
+		var err error
+		s.BlobStore, err = cas.NewStore(cfg.Cache.Dir, cfg.Cache.Size, cfg.Cache.Hardlink)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file cache: %w", err)
+		}
+	}
+	if cfg.StateDir != "" {
+		var err error
+		s.Journal, err = process.OpenJournal(cfg.StateDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open journal: %w", err)
+		}
+		err = s.Journal.Recover()
+		if err != nil {
+			s.logger().Warn("Failed to recover jobs", "err", err)
+		}
+	}
+	return s, nil
+}
+
+// lookupUID returns the uid of a user name or uid.
+func lookupUID(name string) (uint32, error) {
+	if uid, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return uint32(uid), nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return 0, fmt.Errorf("invalid allowed user: %w", err)
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid uid of user %s: %w", name, err)
+	}
+	return uint32(uid), nil
+}
+
+// Reload applies the settings of cfg which can be changed at runtime, i.e.
+// the limits of running jobs and the output buffer.
+func (s *Server) Reload(cfg *config.Config) {
+	s.mutex.Lock()
+	s.OutputBufferSize = cfg.OutputBuffer.Size
+	s.OutputBufferMax = cfg.OutputBuffer.Max
+	s.MaxRunning = cfg.MaxRunning
+	s.MaxRunningPerCaller = cfg.MaxRunningPerCaller
+	s.mutex.Unlock()
+	s.jobQueue().SetLimits(cfg.MaxRunning, cfg.MaxRunningPerCaller)
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"github.com/reyoung/rce/protocol"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// jobs tracks the Spawn streams, so the server can be drained.
+type jobs struct {
+	draining bool
+	nextID   uint64
+	cancels  map[uint64]context.CancelFunc
+	// changed is closed and replaced when a job ends.
+	changed chan struct{}
+}
+
+// beginJob registers a Spawn stream with its cancel function, it fails if
+// the server is draining.
+func (s *Server) beginJob(cancel context.CancelFunc) (uint64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.jobs.draining {
+		return 0, status.Error(codes.Unavailable, "server is draining")
+	}
+	if s.jobs.cancels == nil {
+		s.jobs.cancels = make(map[uint64]context.CancelFunc)
+		s.jobs.changed = make(chan struct{})
+	}
+	s.jobs.nextID++
+	s.jobs.cancels[s.jobs.nextID] = cancel
+	return s.jobs.nextID, nil
+}
+
+func (s *Server) endJob(id uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.jobs.cancels, id)
+	close(s.jobs.changed)
+	s.jobs.changed = make(chan struct{})
+}
+
+// waitJobs waits until all jobs end, and returns the number of running jobs
+// if ctx is done.
+func (s *Server) waitJobs(ctx context.Context) int {
+	for {
+		s.mutex.RLock()
+		running, changed := len(s.jobs.cancels), s.jobs.changed
+		s.mutex.RUnlock()
+		if running == 0 {
+			return 0
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return running
+		}
+	}
+}
+
+func (s *Server) drain() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.jobs.draining {
+		s.logger().Info("Draining, new jobs are rejected")
+	}
+	s.jobs.draining = true
+}
+
+func (s *Server) Drain(ctx context.Context, req *protocol.DrainRequest) (*protocol.DrainResponse, error) {
+	s.drain()
+	if !req.Wait {
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+		return &protocol.DrainResponse{Running: uint32(len(s.jobs.cancels))}, nil
+	}
+	running := s.waitJobs(ctx)
+	if running != 0 {
+		return &protocol.DrainResponse{Running: uint32(running), Error: ctx.Err().Error()}, nil
+	}
+	return &protocol.DrainResponse{}, nil
+}
+
+// Shutdown drains the server and waits for the running jobs until ctx is
+// done, then kills the remaining jobs. The workspace of a job is removed
+// when its Spawn stream returns, so the grpc server should be stopped with
+// grpc.WaitForHandlers.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.drain()
+	running := s.waitJobs(ctx)
+	if running == 0 {
+		return
+	}
+	s.logger().Info("Killing running jobs", "jobs", running)
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, cancel := range s.jobs.cancels {
+		cancel()
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/reyoung/rce/protocol"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"os"
+	"time"
+)
+
+var (
+	errDraining   = errors.New("server is draining")
+	errAtCapacity = errors.New("server is at capacity")
+)
+
+// checkReady returns why the server is not ready for new jobs, nil if it is
+// ready.
+func (s *Server) checkReady() error {
+	s.mutex.RLock()
+	draining := s.jobs.draining
+	s.mutex.RUnlock()
+	if draining {
+		return errDraining
+	}
+	if s.jobQueue().Full() {
+		return errAtCapacity
+	}
+	// workspaces are created in the temp dir.
+	f, err := os.CreateTemp("", "rce-health")
+	if err != nil {
+		return fmt.Errorf("workspace dir is not writable: %w", err)
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write([]byte{0})
+	err = errors.Join(err, f.Close())
+	if err != nil {
+		return fmt.Errorf("workspace dir is not writable: %w", err)
+	}
+	return nil
+}
+
+// ServeHealth sets the serving status of the server and of the
+// RemoteCodeExecutor service in h every interval until ctx is done. They are
+// SERVING if the server is ready for new jobs, i.e. it is not draining, the
+// running jobs are below MaxRunning and the workspace dir is writable.
+func (s *Server) ServeHealth(ctx context.Context, h *health.Server, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var last error
+	for {
+		err := s.checkReady()
+		status := healthpb.HealthCheckResponse_SERVING
+		if err != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+			if last == nil || err.Error() != last.Error() {
+				s.logger().Info("Server is not ready", "err", err)
+			}
+		} else if last != nil {
+			s.logger().Info("Server is ready")
+		}
+		last = err
+		h.SetServingStatus("", status)
+		h.SetServingStatus(protocol.RemoteCodeExecutor_ServiceDesc.ServiceName, status)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"github.com/reyoung/rce/process"
+	"github.com/reyoung/rce/protocol"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Version of the server, it can be set by
+// -ldflags "-X github.com/reyoung/rce/server.Version=...".
+var Version = "1.0"
+
+// shells returns the login shells listed in /etc/shells which exist.
+func shells() []string {
+	f, err := os.Open("/etc/shells")
+	if err != nil {
+		if _, err := os.Stat("/bin/sh"); err == nil {
+			return []string{"/bin/sh"}
+		}
+		return nil
+	}
+	defer f.Close()
+	var res []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, err := os.Stat(line); err == nil {
+			res = append(res, line)
+		}
+	}
+	return res
+}
+
+// loadAverage returns the 1 minute load average, zero if unknown.
+func loadAverage() float64 {
+	buf, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0
+	}
+	fields := bytes.Fields(buf)
+	if len(fields) == 0 {
+		return 0
+	}
+	load, _ := strconv.ParseFloat(string(fields[0]), 64)
+	return load
+}
+
+func (s *Server) features() []protocol.Feature {
+	features := []protocol.Feature{
+		protocol.Feature_FEATURE_PTY,
+		protocol.Feature_FEATURE_ARCHIVE,
+		protocol.Feature_FEATURE_FILE_METADATA,
+		protocol.Feature_FEATURE_OUTPUT_BUFFER,
+		protocol.Feature_FEATURE_TIMESTAMPS,
+		protocol.Feature_FEATURE_MERGE_STDERR,
+		protocol.Feature_FEATURE_SEPARATE_STDERR,
+		protocol.Feature_FEATURE_PRIORITY,
+		protocol.Feature_FEATURE_DRAIN,
+		protocol.Feature_FEATURE_SIGNAL,
+	}
+	if s.BlobStore != nil {
+		features = append(features, protocol.Feature_FEATURE_FILE_CACHE)
+	}
+	return features
+}
+
+func (s *Server) Info(ctx context.Context, req *protocol.InfoRequest) (*protocol.InfoResponse, error) {
+	hostname, _ := os.Hostname()
+	running, queued := s.jobQueue().Stats()
+	s.mutex.RLock()
+	limits := &protocol.InfoResponse_Limits{
+		MaxRunning:          uint32(s.MaxRunning),
+		MaxRunningPerCaller: uint32(s.MaxRunningPerCaller),
+		OutputBufferSize:    uint64(s.OutputBufferSize),
+		OutputBufferMax:     uint64(s.OutputBufferMax),
+	}
+	draining := s.jobs.draining
+	s.mutex.RUnlock()
+	if limits.OutputBufferSize == 0 || limits.OutputBufferMax == 0 {
+		limits.OutputBufferSize, limits.OutputBufferMax = process.DefaultOutputBufferSize, process.DefaultOutputBufferMax
+	}
+	return &protocol.InfoResponse{
+		Version:     Version,
+		Os:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		Hostname:    hostname,
+		Shells:      shells(),
+		Features:    s.features(),
+		Compressors: s.Compressors,
+		Limits:      limits,
+		Load: &protocol.InfoResponse_Load{
+			Running:     uint32(running),
+			Queued:      uint32(queued),
+			Draining:    draining,
+			LoadAverage: loadAverage(),
+		},
+	}, nil
+}
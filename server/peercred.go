@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"net"
+	"slices"
+	"strconv"
+)
+
+// PeerCred is the AuthInfo of the callers connected through unix sockets,
+// which identifies the local user of the caller.
+type PeerCred struct {
+	credentials.CommonAuthInfo
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+func (c *PeerCred) AuthType() string {
+	return "peercred"
+}
+
+type peerCredentials struct {
+	credentials.TransportCredentials
+}
+
+// Credentials returns the transport credentials of the server. Like the
+// insecure credentials the connections are not encrypted, and the callers
+// connected through unix sockets are identified by SO_PEERCRED.
+func Credentials() credentials.TransportCredentials {
+	return peerCredentials{insecure.NewCredentials()}
+}
+
+func (c peerCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return c.TransportCredentials.ServerHandshake(conn)
+	}
+	cred, err := getPeerCred(uc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get peer credentials: %w", err)
+	}
+	if cred == nil { // not supported
+		return c.TransportCredentials.ServerHandshake(conn)
+	}
+	cred.CommonAuthInfo = credentials.CommonAuthInfo{SecurityLevel: credentials.NoSecurity}
+	return conn, cred, nil
+}
+
+func (c peerCredentials) Clone() credentials.TransportCredentials {
+	return peerCredentials{c.TransportCredentials.Clone()}
+}
+
+// peerCredOf returns the local user of the caller of ctx, nil if the caller
+// is not connected through a unix socket.
+func peerCredOf(ctx context.Context) *PeerCred {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	cred, _ := p.AuthInfo.(*PeerCred)
+	return cred
+}
+
+// authorize checks whether the caller of ctx is allowed. Only the callers
+// connected through unix sockets are identified, and they are checked
+// against AllowedUIDs.
+func (s *Server) authorize(ctx context.Context) error {
+	if len(s.AllowedUIDs) == 0 {
+		return nil
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil || p.Addr.Network() != "unix" {
+		return nil
+	}
+	cred := peerCredOf(ctx)
+	if cred == nil {
+		return status.Error(codes.PermissionDenied, "local user is unknown")
+	}
+	if !slices.Contains(s.AllowedUIDs, cred.UID) {
+		return status.Error(codes.PermissionDenied, "local user "+strconv.FormatUint(uint64(cred.UID), 10)+" is not allowed")
+	}
+	return nil
+}
+
+// UnaryInterceptor authorizes the unary calls.
+func (s *Server) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (any, error) {
+	err := s.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor authorizes the stream calls.
+func (s *Server) StreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	err := s.authorize(ss.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"github.com/reyoung/rce/protocol"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+)
+
+func startUnixServer(t *testing.T, s *Server, opts ...grpc.ServerOption) protocol.RemoteCodeExecutorClient {
+	sock := path.Join(t.TempDir(), "rce.sock")
+	lis, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svr := grpc.NewServer(append(opts, grpc.Creds(Credentials()))...)
+	protocol.RegisterRemoteCodeExecutorServer(svr, s)
+	go func() {
+		_ = svr.Serve(lis)
+	}()
+	t.Cleanup(svr.Stop)
+
+	conn, err := grpc.NewClient("unix://"+sock, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return protocol.NewRemoteCodeExecutorClient(conn)
+}
+
+func startAuthorizingServer(t *testing.T, s *Server) protocol.RemoteCodeExecutorClient {
+	return startUnixServer(t, s, grpc.UnaryInterceptor(s.UnaryInterceptor), grpc.StreamInterceptor(s.StreamInterceptor))
+}
+
+func TestPeerCredAuthorize(t *testing.T) {
+	uid := uint32(os.Getuid())
+	client := startAuthorizingServer(t, &Server{AllowedUIDs: []uint32{uid}})
+	rsp, err := client.Kill(context.Background(), &protocol.PID{Id: "unknown"})
+	if err != nil || rsp.Error == "" {
+		t.Fatalf("unexpected kill response %v, err %v", rsp, err)
+	}
+	if _, code := runCommand(t, client, "true"); code != 0 {
+		t.Fatalf("unexpected exit code %d", code)
+	}
+
+	client = startAuthorizingServer(t, &Server{AllowedUIDs: []uint32{uid + 1}})
+	_, err = client.Kill(context.Background(), &protocol.PID{Id: "unknown"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expect permission denied, got %v", err)
+	}
+	cli, err := client.Spawn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cli.Recv()
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expect permission denied, got %v", err)
+	}
+}
+
+func TestCallerOfPeerCred(t *testing.T) {
+	var caller string
+	client := startUnixServer(t, &Server{}, grpc.UnaryInterceptor(
+		func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			caller = callerOf(ctx)
+			return handler(ctx, req)
+		}))
+	_, err := client.Kill(context.Background(), &protocol.PID{Id: "unknown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "uid:" + strconv.Itoa(os.Getuid()); caller != expected {
+		t.Fatalf("expect caller %s, got %s", expected, caller)
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"github.com/reyoung/rce/metrics"
+	"google.golang.org/grpc/peer"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// jobQueue limits the number of running jobs. Jobs exceeding the limits
+// wait in the queue, ordered by priority and then by arrival.
+type jobQueue struct {
+	maxRunning   int // <= 0 means unlimited
+	maxPerCaller int // <= 0 means unlimited
+
+	mutex     sync.Mutex
+	running   int
+	perCaller map[string]int
+	queue     []*ticket
+}
+
+type ticket struct {
+	caller   string
+	priority int32
+	started  bool
+	ready    chan struct{}
+	// position receives the latest position of the ticket in the queue.
+	position     chan int
+	lastPosition int
+}
+
+func newJobQueue(maxRunning, maxPerCaller int) *jobQueue {
+	return &jobQueue{
+		maxRunning:   maxRunning,
+		maxPerCaller: maxPerCaller,
+		perCaller:    make(map[string]int),
+	}
+}
+
+// SetLimits changes the limits, the queued jobs start if the limits are
+// raised.
+func (q *jobQueue) SetLimits(maxRunning, maxPerCaller int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.maxRunning, q.maxPerCaller = maxRunning, maxPerCaller
+	q.dispatch()
+}
+
+// Stats returns the number of running and queued jobs.
+func (q *jobQueue) Stats() (running, queued int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.running, len(q.queue)
+}
+
+// Full returns whether new jobs are queued since the running jobs reach
+// maxRunning.
+func (q *jobQueue) Full() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.maxRunning > 0 && q.running >= q.maxRunning
+}
+
+// canStart returns whether a job of caller can start. mutex must be held.
+func (q *jobQueue) canStart(caller string) bool {
+	return (q.maxRunning <= 0 || q.running < q.maxRunning) &&
+		(q.maxPerCaller <= 0 || q.perCaller[caller] < q.maxPerCaller)
+}
+
+// dispatch starts the queued jobs within the limits, and notifies the
+// others of their new positions. mutex must be held.
+func (q *jobQueue) dispatch() {
+	waiting := q.queue[:0]
+	for _, t := range q.queue {
+		if !q.canStart(t.caller) {
+			waiting = append(waiting, t)
+			continue
+		}
+		q.running++
+		q.perCaller[t.caller]++
+		t.started = true
+		close(t.ready)
+	}
+	clear(q.queue[len(waiting):])
+	q.queue = waiting
+	metrics.SpawnsQueued.Set(float64(len(q.queue)))
+
+	for i, t := range q.queue {
+		if t.lastPosition == i+1 {
+			continue
+		}
+		t.lastPosition = i + 1
+		select { // replace the position not received yet.
+		case <-t.position:
+		default:
+		}
+		t.position <- i + 1
+	}
+}
+
+func (q *jobQueue) release(t *ticket) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	if !t.started {
+		for i, queued := range q.queue {
+			if queued == t {
+				q.queue = append(q.queue[:i], q.queue[i+1:]...)
+				break
+			}
+		}
+	} else {
+		q.running--
+		q.perCaller[t.caller]--
+		if q.perCaller[t.caller] == 0 {
+			delete(q.perCaller, t.caller)
+		}
+	}
+	q.dispatch()
+}
+
+// Acquire waits until a job of caller can start. queued is called with the
+// position of the job whenever it changes while waiting. The returned
+// release must be called when the job ends.
+func (q *jobQueue) Acquire(ctx context.Context, caller string, priority int32,
+	queued func(position int) error) (release func(), err error) {
+	t := &ticket{
+		caller:   caller,
+		priority: priority,
+		ready:    make(chan struct{}),
+		position: make(chan int, 1),
+	}
+	q.mutex.Lock()
+	i := len(q.queue)
+	for i > 0 && q.queue[i-1].priority < priority {
+		i--
+	}
+	q.queue = append(q.queue, nil)
+	copy(q.queue[i+1:], q.queue[i:])
+	q.queue[i] = t
+	q.dispatch()
+	q.mutex.Unlock()
+
+	release = func() {
+		q.release(t)
+	}
+	for {
+		select {
+		case <-t.ready:
+			return release, nil
+		case position := <-t.position:
+			err = queued(position)
+			if err != nil {
+				release()
+				return nil, err
+			}
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// callerOf identifies the caller of ctx for the per caller limits. Callers
+// through unix sockets are identified by their local users.
+func callerOf(ctx context.Context) string {
+	if cred := peerCredOf(ctx); cred != nil {
+		return "uid:" + strconv.FormatUint(uint64(cred.UID), 10)
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
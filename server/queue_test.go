@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type queuedJob struct {
+	release   chan func()
+	positions chan int
+}
+
+func acquireAsync(q *jobQueue, ctx context.Context, caller string, priority int32) *queuedJob {
+	j := &queuedJob{release: make(chan func(), 1), positions: make(chan int, 16)}
+	go func() {
+		release, err := q.Acquire(ctx, caller, priority, func(position int) error {
+			j.positions <- position
+			return nil
+		})
+		if err != nil {
+			close(j.release)
+			return
+		}
+		j.release <- release
+	}()
+	return j
+}
+
+func (j *queuedJob) started(t *testing.T) func() {
+	t.Helper()
+	select {
+	case release, ok := <-j.release:
+		if !ok {
+			t.Fatal("acquire failed")
+		}
+		return release
+	case <-time.After(time.Second):
+		t.Fatal("job is not started")
+	}
+	return nil
+}
+
+func (j *queuedJob) position(t *testing.T, expected int) {
+	t.Helper()
+	select {
+	case position := <-j.positions:
+		if position != expected {
+			t.Fatalf("expect position %d, got %d", expected, position)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no position %d", expected)
+	}
+}
+
+func TestJobQueuePriority(t *testing.T) {
+	q := newJobQueue(1, 0)
+	ctx := context.Background()
+	release := acquireAsync(q, ctx, "a", 0).started(t)
+
+	low := acquireAsync(q, ctx, "a", 0)
+	low.position(t, 1)
+	high := acquireAsync(q, ctx, "a", 1)
+	high.position(t, 1)
+	low.position(t, 2)
+
+	release()
+	release = high.started(t)
+	low.position(t, 1)
+	release()
+	low.started(t)()
+}
+
+func TestJobQueuePerCaller(t *testing.T) {
+	q := newJobQueue(0, 1)
+	ctx := context.Background()
+	release := acquireAsync(q, ctx, "a", 0).started(t)
+	a := acquireAsync(q, ctx, "a", 0)
+	a.position(t, 1)
+	// other callers are not blocked by the queued job of a.
+	acquireAsync(q, ctx, "b", 0).started(t)()
+	release()
+	a.started(t)()
+}
+
+func TestJobQueueCancel(t *testing.T) {
+	q := newJobQueue(1, 0)
+	release := acquireAsync(q, context.Background(), "a", 0).started(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := acquireAsync(q, ctx, "a", 0)
+	canceled.position(t, 1)
+	waiting := acquireAsync(q, context.Background(), "a", 0)
+	waiting.position(t, 2)
+
+	cancel()
+	if _, ok := <-canceled.release; ok {
+		t.Fatal("canceled job is started")
+	}
+	waiting.position(t, 1)
+	release()
+	waiting.started(t)()
+}
+
+func TestJobQueueSetLimits(t *testing.T) {
+	q := newJobQueue(1, 0)
+	ctx := context.Background()
+	release := acquireAsync(q, ctx, "a", 0).started(t)
+	queued := acquireAsync(q, ctx, "a", 0)
+	queued.position(t, 1)
+	q.SetLimits(2, 0)
+	queued.started(t)()
+	release()
+}
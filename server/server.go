@@ -3,19 +3,77 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/reyoung/rce/cas"
+	"github.com/reyoung/rce/metrics"
 	"github.com/reyoung/rce/process"
 	"github.com/reyoung/rce/protocol"
-	"log"
+	"github.com/reyoung/rce/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"slices"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 type Server struct {
 	protocol.UnimplementedRemoteCodeExecutorServer
 
+	// BlobStore is the server side file cache, nil disables the cache.
+	BlobStore *cas.Store
+	// Journal records the workspaces and processes of jobs, nil disables it.
+	Journal *process.Journal
+	// Logger of the server, nil uses slog.Default.
+	Logger *slog.Logger
+	// Compressors of responses in preference order. The first one supported
+	// by the client is used.
+	Compressors []string
+	// OutputBufferSize is the default output buffer size of processes, and
+	// OutputBufferMax limits the size requested by clients. Zero uses the
+	// process defaults.
+	OutputBufferSize int
+	OutputBufferMax  int
+	// MaxRunning limits the running jobs, and MaxRunningPerCaller limits the
+	// running jobs of a caller. Jobs exceeding the limits are queued after
+	// Start. Zero means unlimited.
+	MaxRunning          int
+	MaxRunningPerCaller int
+	// AllowedUIDs are the local users allowed to call through unix sockets,
+	// empty allows all. The interceptors of the server must be installed.
+	AllowedUIDs []uint32
+
+	queue     *jobQueue
+	queueOnce sync.Once
+	jobs      jobs
+
 	processes map[string]process.Process
 	mutex     sync.RWMutex
 }
 
+func (s *Server) logger() *slog.Logger {
+	if s.Logger == nil {
+		return slog.Default()
+	}
+	return s.Logger
+}
+
+func (s *Server) jobQueue() *jobQueue {
+	s.queueOnce.Do(func() {
+		s.mutex.RLock()
+		defer s.mutex.RUnlock()
+		s.queue = newJobQueue(s.MaxRunning, s.MaxRunningPerCaller)
+	})
+	return s.queue
+}
+
 func forceClose(c chan struct{}) {
 	defer func() {
 		recover()
@@ -54,40 +112,168 @@ func (p *processSetter) Unset() {
 	delete(p.s.processes, p.pid)
 }
 
+// negotiateCompressor compresses the responses of the stream with the
+// preferred compressor which the client supports.
+func (s *Server) negotiateCompressor(ctx context.Context, logger *slog.Logger) {
+	supported, err := grpc.ClientSupportedCompressors(ctx)
+	if err != nil {
+		return
+	}
+	for _, name := range s.Compressors {
+		if !slices.Contains(supported, name) {
+			continue
+		}
+		err = grpc.SetSendCompressor(ctx, name)
+		if err != nil {
+			logger.Warn("Failed to set compressor", "compressor", name, "err", err)
+		}
+		return
+	}
+}
+
 func (s *Server) Spawn(svr protocol.RemoteCodeExecutor_SpawnServer) error {
-	p := process.New(svr.Context())
+	ctx, cancel := context.WithCancel(svr.Context())
+	defer cancel()
+	// set once Start is passed to the process, the process may start since.
+	var started atomic.Bool
+	// set if the files can not be prepared, the process never starts then.
+	var prepareFailed atomic.Bool
 	defer func() {
-		log.Printf("Closing process")
+		if !started.Load() || prepareFailed.Load() {
+			svr.SetTrailer(metadata.Pairs(protocol.NotStartedTrailer, "true"))
+		}
+	}()
+	id, err := s.beginJob(cancel)
+	logger := s.logger().With("job", id, "caller", callerOf(ctx))
+	s.negotiateCompressor(ctx, logger)
+	if err != nil {
+		logger.Info("Rejected job", "err", err)
+		metrics.SpawnsTotal.WithLabelValues(metrics.OutcomeRejected).Inc()
+		return err
+	}
+	metrics.SpawnsActive.Inc()
+	outcome := metrics.OutcomeCanceled
+	// the job ends when the process ends, even if the client keeps the stream.
+	var jobEnded sync.Once
+	endJob := func() {
+		jobEnded.Do(func() {
+			s.endJob(id)
+			metrics.SpawnsActive.Dec()
+			metrics.SpawnsTotal.WithLabelValues(outcome).Inc()
+		})
+	}
+	defer endJob()
+
+	opts := []process.Option{
+		process.WithBlobStore(s.BlobStore),
+		process.WithJournal(s.Journal),
+		process.WithLogger(logger),
+	}
+	s.mutex.RLock()
+	bufferSize, bufferMax := s.OutputBufferSize, s.OutputBufferMax
+	s.mutex.RUnlock()
+	if bufferSize > 0 && bufferMax > 0 {
+		opts = append(opts, process.WithOutputBuffer(bufferSize, bufferMax))
+	}
+	p := process.New(ctx, opts...)
+
+	queue := s.jobQueue()
+	// the queue is released when the process exits, or after the process is
+	// closed if it never exits. release is set by the recv goroutine.
+	var releaseMutex sync.Mutex
+	var release func()
+	releaseQueue := func() {
+		releaseMutex.Lock()
+		defer releaseMutex.Unlock()
+		if release != nil {
+			release()
+			release = nil
+		}
+	}
+	defer releaseQueue()
+	defer func() {
+		logger.Debug("Closing process")
 		_ = p.Close()
 	}()
 
+	// responses are sent by the queue and the process.
+	var sendMutex sync.Mutex
+	send := func(rsp *protocol.SpawnResponse) error {
+		sendMutex.Lock()
+		defer sendMutex.Unlock()
+		return svr.Send(rsp)
+	}
+
+	// headTime is set before Head is sent to the process, so it is read
+	// safely when the PID is received.
+	var headTime time.Time
 	exited := make(chan struct{})
 	var complete sync.WaitGroup
 	complete.Add(2) // 2 means send/recv
+	// recvErr is set by the recv goroutine, err by the send goroutine.
+	var recvErr error
 
 	go func() {
 		defer func() {
 			complete.Done()
-			log.Printf("Writing request goroutine exit")
+			logger.Debug("Writing request goroutine exit")
 		}()
+		var priority int32
 		for {
 			req, err := svr.Recv()
 			if err != nil {
 				forceClose(exited)
 				return
 			}
-			log.Printf("Received request: %T", req.GetPayload())
-			p.RequestChan() <- req
+			if req.GetStdin() == nil { // stdin frames are too many to log.
+				logger.Debug("Received request", "type", fmt.Sprintf("%T", req.GetPayload()))
+			}
+			switch req.GetPayload().(type) {
+			case *protocol.SpawnRequest_Head_:
+				priority = req.GetHead().Priority
+				headTime = time.Now()
+			case *protocol.SpawnRequest_Start_:
+				// the queue is acquired once, a duplicate Start would leak it.
+				if started.Load() {
+					recvErr = status.Error(codes.InvalidArgument, "duplicate start")
+					forceClose(exited)
+					return
+				}
+				_, span := tracing.Tracer().Start(ctx, "queued")
+				acquired, err := queue.Acquire(ctx, callerOf(ctx), priority, func(position int) error {
+					span.AddEvent("position", trace.WithAttributes(attribute.Int("position", position)))
+					return send(&protocol.SpawnResponse{Payload: &protocol.SpawnResponse_Queued_{
+						Queued: &protocol.SpawnResponse_Queued{Position: uint32(position)}}})
+				})
+				span.End()
+				if err != nil {
+					recvErr = err
+					forceClose(exited)
+					return
+				}
+				releaseMutex.Lock()
+				release = acquired
+				releaseMutex.Unlock()
+				started.Store(true)
+			}
+			// the process stops reading requests once it fails.
+			select {
+			case p.RequestChan() <- req:
+			case <-exited:
+				return
+			}
 		}
 	}()
 
-	var err error
 	pidSetter := &processSetter{s: s, p: p}
 	defer pidSetter.Unset()
 
 	go func() {
 		defer func() {
-			log.Printf("Reading response goroutine exit")
+			logger.Debug("Reading response goroutine exit")
+			endJob()
+			// the client may keep the stream after the exit.
+			releaseQueue()
 			forceClose(exited)
 			complete.Done()
 
@@ -103,7 +289,8 @@ func (s *Server) Spawn(svr protocol.RemoteCodeExecutor_SpawnServer) error {
 			select {
 			case rsp := <-p.ResponseChan():
 				pidSetter.TrySet()
-				err = svr.Send(rsp)
+				observeResponse(rsp, headTime, &outcome)
+				err = send(rsp)
 				if err != nil {
 					return
 				}
@@ -113,9 +300,17 @@ func (s *Server) Spawn(svr protocol.RemoteCodeExecutor_SpawnServer) error {
 					return
 				}
 
+				outcome = metrics.OutcomeError
+				if errors.Is(err, cas.ErrBlobNotFound) {
+					// the blob is evicted after FindMissingBlobs, the client
+					// uploads it again.
+					prepareFailed.Store(true)
+					err = status.Error(codes.Aborted, err.Error())
+					return
+				}
 				rsp := &protocol.SpawnResponse{Payload: &protocol.SpawnResponse_Error{
 					Error: &protocol.SpawnResponse_SystemError{Error: err.Error()}}}
-				err = errors.Join(err, svr.Send(rsp))
+				err = errors.Join(err, send(rsp))
 				return
 			case <-exited:
 				return
@@ -123,20 +318,46 @@ func (s *Server) Spawn(svr protocol.RemoteCodeExecutor_SpawnServer) error {
 		}
 	}()
 	complete.Wait()
+	if recvErr != nil {
+		return recvErr
+	}
 	return err
 }
 
+// observeResponse updates the metrics of a response to be sent.
+func observeResponse(rsp *protocol.SpawnResponse, headTime time.Time, outcome *string) {
+	switch v := rsp.Payload.(type) {
+	case *protocol.SpawnResponse_Stdout_:
+		metrics.StreamedBytes.WithLabelValues("stdout").Add(float64(len(v.Stdout.Stdout)))
+	case *protocol.SpawnResponse_Stderr_:
+		metrics.StreamedBytes.WithLabelValues("stderr").Add(float64(len(v.Stderr.Stderr)))
+	case *protocol.SpawnResponse_Pid:
+		metrics.SpawnLatency.Observe(time.Since(headTime).Seconds())
+	case *protocol.SpawnResponse_Exit_:
+		*outcome = metrics.OutcomeExited
+		metrics.ExitCodes.WithLabelValues(strconv.Itoa(int(v.Exit.Code))).Inc()
+	}
+}
+
 func (s *Server) Kill(ctx context.Context, pid *protocol.PID) (*protocol.KillResponse, error) {
-	log.Printf("Received kill request: %v", pid.String())
+	s.logger().Info("Received kill request", "pid", pid.Id, "signal", pid.Signal)
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 	p, ok := s.processes[pid.Id]
 	if !ok {
+		metrics.KillRequests.WithLabelValues("not_found").Inc()
 		return &protocol.KillResponse{Error: "process not found"}, nil
 	}
-	err := p.Kill()
+	var err error
+	if pid.Signal != 0 {
+		err = p.Signal(syscall.Signal(pid.Signal))
+	} else {
+		err = p.Kill()
+	}
 	if err != nil {
+		metrics.KillRequests.WithLabelValues("error").Inc()
 		return &protocol.KillResponse{Error: err.Error()}, nil
 	}
+	metrics.KillRequests.WithLabelValues("ok").Inc()
 	return nil, nil
 }
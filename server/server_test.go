@@ -0,0 +1,291 @@
+package server
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/reyoung/rce/compression"
+	"github.com/reyoung/rce/metrics"
+	"github.com/reyoung/rce/protocol"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"io"
+	"net"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingConn counts the bytes read from the wire.
+type countingConn struct {
+	net.Conn
+	read *atomic.Int64
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+func startTestServer(t testing.TB, s *Server, wireBytes *atomic.Int64) protocol.RemoteCodeExecutorClient {
+	lis := bufconn.Listen(1 << 20)
+	svr := grpc.NewServer()
+	protocol.RegisterRemoteCodeExecutorServer(svr, s)
+	go func() {
+		_ = svr.Serve(lis)
+	}()
+	t.Cleanup(svr.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			c, err := lis.DialContext(ctx)
+			if err != nil || wireBytes == nil {
+				return c, err
+			}
+			return &countingConn{Conn: c, read: wireBytes}, nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return protocol.NewRemoteCodeExecutorClient(conn)
+}
+
+// runCommand runs a command through client, and returns the stdout size and
+// the exit code.
+func runCommand(t testing.TB, client protocol.RemoteCodeExecutorClient, command string, args ...string) (int64, int32) {
+	cli, err := client.Spawn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range []*protocol.SpawnRequest{
+		{Payload: &protocol.SpawnRequest_Head_{Head: &protocol.SpawnRequest_Head{Command: command, Args: args}}},
+		{Payload: &protocol.SpawnRequest_Start_{Start: &protocol.SpawnRequest_Start{}}},
+	} {
+		if err = cli.Send(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var stdout int64
+	for {
+		rsp, err := cli.Recv()
+		if err != nil {
+			if err == io.EOF {
+				t.Fatal("unexpected EOF")
+			}
+			t.Fatal(err)
+		}
+		stdout += int64(len(rsp.GetStdout().GetStdout()))
+		if rsp.GetError() != nil {
+			t.Fatal(rsp.GetError().Error)
+		}
+		if rsp.GetExit() != nil {
+			return stdout, rsp.GetExit().Code
+		}
+	}
+}
+
+// BenchmarkSpawnOutput measures the throughput of a 1 GB log like stdout
+// stream, and reports the bytes on the wire per output byte.
+func BenchmarkSpawnOutput(b *testing.B) {
+	const outputSize = 1 << 30
+	if err := compression.Register(compression.Gzip, compression.Zstd); err != nil {
+		b.Fatal(err)
+	}
+	script := "yes '2024-06-01T12:00:00Z INFO compiling package github.com/reyoung/rce/process' | head -c " +
+		strconv.Itoa(outputSize)
+
+	for _, c := range []string{compression.None, compression.Gzip, compression.Zstd} {
+		b.Run(c, func(b *testing.B) {
+			var wireBytes atomic.Int64
+			client := startTestServer(b, &Server{Compressors: []string{c}}, &wireBytes)
+			b.SetBytes(outputSize)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				stdout, code := runCommand(b, client, "sh", "-c", script)
+				if stdout != outputSize || code != 0 {
+					b.Fatalf("unexpected output %d bytes, exit code %d", stdout, code)
+				}
+			}
+			b.ReportMetric(float64(wireBytes.Load())/float64(outputSize)/float64(b.N), "wire/op-byte")
+		})
+	}
+}
+
+func TestDrain(t *testing.T) {
+	client := startTestServer(t, &Server{}, nil)
+	if _, code := runCommand(t, client, "true"); code != 0 {
+		t.Fatalf("unexpected exit code %d", code)
+	}
+	rsp, err := client.Drain(context.Background(), &protocol.DrainRequest{Wait: true})
+	if err != nil || rsp.Error != "" || rsp.Running != 0 {
+		t.Fatalf("unexpected drain response %v, err %v", rsp, err)
+	}
+	cli, err := client.Spawn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cli.Recv()
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expect unavailable, got %v", err)
+	}
+	if v := cli.Trailer().Get(protocol.NotStartedTrailer); len(v) != 1 || v[0] != "true" {
+		t.Fatalf("expect not started trailer, got %v", cli.Trailer())
+	}
+}
+
+func TestDuplicateStart(t *testing.T) {
+	client := startTestServer(t, &Server{MaxRunning: 1}, nil)
+	cli, err := client.Spawn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range []*protocol.SpawnRequest{
+		{Payload: &protocol.SpawnRequest_Head_{Head: &protocol.SpawnRequest_Head{Command: "sleep", Args: []string{"30"}}}},
+		{Payload: &protocol.SpawnRequest_Start_{Start: &protocol.SpawnRequest_Start{}}},
+		{Payload: &protocol.SpawnRequest_Start_{Start: &protocol.SpawnRequest_Start{}}},
+	} {
+		if err = cli.Send(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for err == nil {
+		_, err = cli.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expect invalid argument, got %v", err)
+	}
+	// the only slot is released, the next job is not queued forever.
+	if _, code := runCommand(t, client, "true"); code != 0 {
+		t.Fatalf("unexpected exit code %d", code)
+	}
+}
+
+func TestReleaseAfterExit(t *testing.T) {
+	client := startTestServer(t, &Server{MaxRunning: 1}, nil)
+	spawn := func(ctx context.Context) protocol.RemoteCodeExecutor_SpawnClient {
+		cli, err := client.Spawn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, req := range []*protocol.SpawnRequest{
+			{Payload: &protocol.SpawnRequest_Head_{Head: &protocol.SpawnRequest_Head{Command: "true"}}},
+			{Payload: &protocol.SpawnRequest_Start_{Start: &protocol.SpawnRequest_Start{}}},
+		} {
+			if err = cli.Send(req); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for {
+			rsp, err := cli.Recv()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rsp.GetExit() != nil {
+				return cli
+			}
+		}
+	}
+	// the first stream is kept open after the exit, the second job is queued
+	// until the deadline if the first one keeps its slot.
+	first := spawn(context.Background())
+	defer first.CloseSend()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	spawn(ctx)
+}
+
+func TestSpawnMetrics(t *testing.T) {
+	client := startTestServer(t, &Server{}, nil)
+	exited := testutil.ToFloat64(metrics.SpawnsTotal.WithLabelValues(metrics.OutcomeExited))
+	code3 := testutil.ToFloat64(metrics.ExitCodes.WithLabelValues("3"))
+	stdout := testutil.ToFloat64(metrics.StreamedBytes.WithLabelValues("stdout"))
+	if _, code := runCommand(t, client, "sh", "-c", "echo hello; exit 3"); code != 3 {
+		t.Fatalf("unexpected exit code %d", code)
+	}
+	// the stream is finished after the exit code is received.
+	deadline := time.Now().Add(10 * time.Second)
+	for testutil.ToFloat64(metrics.SpawnsTotal.WithLabelValues(metrics.OutcomeExited)) == exited {
+		if time.Now().After(deadline) {
+			t.Fatal("spawn is not counted")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if v := testutil.ToFloat64(metrics.SpawnsTotal.WithLabelValues(metrics.OutcomeExited)); v != exited+1 {
+		t.Fatalf("unexpected exited spawns %v", v-exited)
+	}
+	if v := testutil.ToFloat64(metrics.ExitCodes.WithLabelValues("3")); v != code3+1 {
+		t.Fatalf("unexpected exit code count %v", v-code3)
+	}
+	if v := testutil.ToFloat64(metrics.StreamedBytes.WithLabelValues("stdout")); v != stdout+6 {
+		t.Fatalf("unexpected stdout bytes %v", v-stdout)
+	}
+}
+
+func waitHealth(t *testing.T, h *health.Server, expected healthpb.HealthCheckResponse_ServingStatus) {
+	t.Helper()
+	req := &healthpb.HealthCheckRequest{Service: protocol.RemoteCodeExecutor_ServiceDesc.ServiceName}
+	deadline := time.Now().Add(time.Second)
+	for {
+		rsp, err := h.Check(context.Background(), req)
+		if err == nil && rsp.Status == expected {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expect %v, got %v, err %v", expected, rsp.GetStatus(), err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServeHealth(t *testing.T) {
+	s := &Server{MaxRunning: 1}
+	h := health.NewServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.ServeHealth(ctx, h, 10*time.Millisecond)
+	waitHealth(t, h, healthpb.HealthCheckResponse_SERVING)
+
+	release, err := s.jobQueue().Acquire(ctx, "a", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitHealth(t, h, healthpb.HealthCheckResponse_NOT_SERVING)
+	release()
+	waitHealth(t, h, healthpb.HealthCheckResponse_SERVING)
+
+	s.drain()
+	waitHealth(t, h, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+func TestInfo(t *testing.T) {
+	s := &Server{MaxRunning: 2}
+	client := startTestServer(t, s, nil)
+	rsp, err := client.Info(context.Background(), &protocol.InfoRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rsp.Os != runtime.GOOS || rsp.Limits.MaxRunning != 2 || rsp.Limits.OutputBufferSize == 0 {
+		t.Fatalf("unexpected info %v", rsp)
+	}
+	if !slices.Contains(rsp.Features, protocol.Feature_FEATURE_PTY) ||
+		slices.Contains(rsp.Features, protocol.Feature_FEATURE_FILE_CACHE) {
+		t.Fatalf("unexpected features %v", rsp.Features)
+	}
+	s.drain()
+	rsp, err = client.Info(context.Background(), &protocol.InfoRequest{})
+	if err != nil || !rsp.Load.Draining {
+		t.Fatalf("expect draining, got %v, err %v", rsp, err)
+	}
+}
@@ -0,0 +1,42 @@
+// Package tracing exports OpenTelemetry traces of jobs over OTLP. The trace
+// context is propagated in gRPC metadata, so the spans of rce_client and
+// rce_server belong to the same trace.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.25.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer returns the tracer of RCE from the global provider, which does
+// nothing until Setup is called.
+func Tracer() trace.Tracer {
+	return otel.Tracer("github.com/reyoung/rce")
+}
+
+// Setup exports the spans of service to the OTLP/gRPC collector at
+// endpoint, e.g. http://localhost:4317. http endpoints are insecure. The
+// returned shutdown flushes the pending spans.
+func Setup(ctx context.Context, service, endpoint string) (shutdown func(context.Context) error, err error) {
+	exporter, err := otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+	res, err := resource.Merge(resource.Default(),
+		resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(service)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/grpc"
+	"net"
+	"testing"
+)
+
+// collector is an OTLP trace collector stub which records the span names.
+type collector struct {
+	coltracepb.UnimplementedTraceServiceServer
+	spans chan string
+}
+
+func (c *collector) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (
+	*coltracepb.ExportTraceServiceResponse, error) {
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, span := range ss.Spans {
+				c.spans <- span.Name
+			}
+		}
+	}
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+func TestSetup(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &collector{spans: make(chan string, 16)}
+	svr := grpc.NewServer()
+	coltracepb.RegisterTraceServiceServer(svr, c)
+	go func() {
+		_ = svr.Serve(lis)
+	}()
+	defer svr.Stop()
+
+	ctx := context.Background()
+	shutdown, err := Setup(ctx, "test", "http://"+lis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, span := Tracer().Start(ctx, "job")
+	span.End()
+	err = shutdown(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case name := <-c.spans:
+		if name != "job" {
+			t.Fatalf("expect span job, got %s", name)
+		}
+	default:
+		t.Fatal("no span is exported")
+	}
+}